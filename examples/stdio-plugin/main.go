@@ -0,0 +1,113 @@
+// Command stdio-plugin is a reference external jspackr plugin. It turns
+// imports of "env:NAME" into a module exporting the value of the
+// environment variable NAME:
+//
+//	import apiURL from "env:API_URL";
+//
+// Build it with go build ./examples/stdio-plugin and enable it with
+//
+//	"plugins": [{ "name": "env", "command": ["./stdio-plugin"], "options": { "prefix": "APP_" } }]
+//
+// The prefix option, if set, restricts the variables that may be read.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kalokaradia/jspackr/src/core/plugin"
+)
+
+type request struct {
+	ID     *int64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type response struct {
+	JSONRPC string    `json:"jsonrpc"`
+	ID      int64     `json:"id"`
+	Result  any       `json:"result,omitempty"`
+	Error   *rpcError `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func main() {
+	var prefix string
+	out := json.NewEncoder(os.Stdout)
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for in.Scan() {
+		var req request
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			fmt.Fprintln(os.Stderr, "env plugin: invalid request:", err)
+			continue
+		}
+		if req.Method == "shutdown" {
+			return
+		}
+		if req.ID == nil {
+			continue
+		}
+
+		result, err := handle(req, &prefix)
+		resp := response{JSONRPC: "2.0", ID: *req.ID, Result: result}
+		if err != nil {
+			resp.Result = nil
+			resp.Error = &rpcError{Code: -32603, Message: err.Error()}
+		}
+		out.Encode(resp)
+	}
+}
+
+// handle answers one request
+func handle(req request, prefix *string) (any, error) {
+	switch req.Method {
+	case "setup":
+		var params struct {
+			Options map[string]any `json:"options"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		*prefix, _ = params.Options["prefix"].(string)
+		return plugin.SetupResult{
+			OnResolve: []plugin.Filter{{Filter: `^env:`}},
+			OnLoad:    []plugin.Filter{{Filter: `.*`, Namespace: "env"}},
+		}, nil
+
+	case "resolve":
+		var args plugin.ResolveArgs
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(args.Path, "env:")
+		if !strings.HasPrefix(name, *prefix) {
+			// Returned as a message so jspackr reports it at the import
+			return plugin.ResolveResult{Errors: []plugin.Message{{
+				Text: fmt.Sprintf("%s may not be read, only variables starting with %s", name, *prefix),
+			}}}, nil
+		}
+		return plugin.ResolveResult{Path: name, Namespace: "env"}, nil
+
+	case "load":
+		var args plugin.LoadArgs
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return nil, err
+		}
+		value, _ := json.Marshal(os.Getenv(args.Path))
+		contents := "export default " + string(value) + ";\n"
+		return plugin.LoadResult{Contents: &contents, Loader: "js"}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown method %q", req.Method)
+	}
+}
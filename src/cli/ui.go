@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,6 +34,27 @@ func DefaultPromptOptions() PromptOptions {
 	}
 }
 
+// stdinReader is shared by all prompts so buffered input is not lost
+// between consecutive questions (e.g. when answers are piped in)
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readLine reads a single trimmed line from stdin
+func readLine() string {
+	input, _ := stdinReader.ReadString('\n')
+	return strings.TrimSpace(input)
+}
+
+// printPrompt prints the icon and message part of a prompt
+func printPrompt(message string, opts PromptOptions) {
+	if opts.Tip != "" {
+		opts.TipColor.Printf("%s %s\n", IconsDefault.Tip, opts.Tip)
+	}
+	if opts.Icon != "" {
+		opts.IconColor.Print(opts.Icon + " ")
+	}
+	opts.TextColor.Print(message + " ")
+}
+
 // Confirm prompts user for yes/no confirmation with styling
 func Confirm(message string, defaultYes bool) bool {
 	opts := DefaultPromptOptions()
@@ -41,37 +63,94 @@ func Confirm(message string, defaultYes bool) bool {
 
 // ConfirmWithOptions prompts user with custom styling
 func ConfirmWithOptions(message string, opts PromptOptions, defaultYes bool) bool {
-	// Create the prompt line
-	prompt := ""
-	
-	if opts.Icon != "" {
-		prompt += opts.Icon + " "
-	}
-	prompt += message + " "
-	
+	printPrompt(message, opts)
+
 	// Default indicator
 	defaultStr := "[y/N]"
 	if defaultYes {
 		defaultStr = "[Y/n]"
 	}
-	
+
 	opts.ArrowColor.Print(defaultStr + ": ")
-	
+
 	// Get input
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
-	
+	input := readLine()
+
 	// Handle empty input with default
 	if input == "" {
 		return defaultYes
 	}
-	
+
 	// Normalize input
 	lower := strings.ToLower(input)
 	return lower == "y" || lower == "yes"
 }
 
+// Prompt asks the user for a line of text, returning defaultValue on empty input
+func Prompt(message, defaultValue string) string {
+	return PromptWithOptions(message, DefaultPromptOptions(), defaultValue)
+}
+
+// PromptWithOptions asks for a line of text with custom styling
+func PromptWithOptions(message string, opts PromptOptions, defaultValue string) string {
+	printPrompt(message, opts)
+	if defaultValue != "" {
+		opts.ArrowColor.Printf("(%s): ", defaultValue)
+	} else {
+		opts.ArrowColor.Print(": ")
+	}
+
+	input := readLine()
+	if input == "" {
+		return defaultValue
+	}
+	return input
+}
+
+// Select asks the user to pick one of choices, by number or by value
+func Select(message string, choices []string, defaultIndex int) string {
+	return SelectWithOptions(message, choices, DefaultPromptOptions(), defaultIndex)
+}
+
+// SelectWithOptions asks the user to pick one of choices with custom styling.
+// Invalid answers are rejected and the question is asked again.
+func SelectWithOptions(message string, choices []string, opts PromptOptions, defaultIndex int) string {
+	if len(choices) == 0 {
+		return ""
+	}
+	if defaultIndex < 0 || defaultIndex >= len(choices) {
+		defaultIndex = 0
+	}
+
+	for {
+		printPrompt(message, opts)
+		fmt.Println()
+		for i, choice := range choices {
+			marker := " "
+			if i == defaultIndex {
+				marker = ">"
+			}
+			opts.ArrowColor.Printf("  %s %d) ", marker, i+1)
+			opts.TextColor.Println(choice)
+		}
+		opts.ArrowColor.Printf("  Choose [1-%d] (%s): ", len(choices), choices[defaultIndex])
+
+		input := readLine()
+		if input == "" {
+			return choices[defaultIndex]
+		}
+		if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(choices) {
+			return choices[n-1]
+		}
+		for _, choice := range choices {
+			if strings.EqualFold(input, choice) {
+				return choice
+			}
+		}
+		DefaultStyles.Warn.Printf("  %s Invalid choice: %s\n", IconsDefault.Warn, input)
+	}
+}
+
 // ConfirmCreateDir prompts user to create a directory
 func ConfirmCreateDir(path string) bool {
 	message := fmt.Sprintf("Directory '%s' does not exist. Create it?", path)
@@ -216,14 +295,52 @@ func PrintTitle() {
 	fmt.Println()
 }
 
+// PrintConfigErrors prints every configuration problem, one per line
+func PrintConfigErrors(errs config.Errors) {
+	problems := "problem"
+	if len(errs) != 1 {
+		problems += "s"
+	}
+	DefaultStyles.Error.Printf("\n%s Invalid configuration (%d %s)\n", IconsDefault.Error, len(errs), problems)
+	errs.Sort()
+	for _, err := range errs {
+		if loc := err.Origin.String(); loc != "" {
+			DefaultStyles.Path.Printf("  %s: ", loc)
+		} else {
+			fmt.Print("  ")
+		}
+		DefaultStyles.Subtitle.Println(err.Message)
+	}
+}
+
+// PrintConfigSource prints which config file is in use
+func PrintConfigSource(path string) {
+	DefaultStyles.Key.Printf("%s Config:", IconsDefault.Space)
+	DefaultStyles.Path.Printf(" %s\n\n", path)
+}
+
 // PrintBuildSummary prints a summary of the build configuration
+// In verbose mode (log level debug) every value is shown with its source.
 func PrintBuildSummary(cfg *config.Config) {
-	DefaultStyles.Section.Println("Build Configuration")
+	if cfg.LogLevel == "debug" {
+		PrintResolvedConfig(cfg)
+		PrintDivider()
+		return
+	}
+
+	if cfg.Name != "" {
+		DefaultStyles.Section.Printf("Build Configuration [%s]\n", cfg.Name)
+	} else {
+		DefaultStyles.Section.Println("Build Configuration")
+	}
 
 	PrintKeyValue("Input", cfg.Input, 0)
 	PrintKeyValue("Output", cfg.Output, 0)
 	PrintKeyValue("Minify", fmt.Sprintf("%t", cfg.Minify), 0)
 	PrintKeyValue("Source Map", cfg.SourceMap, 0)
+	PrintKeyValue("Format", cfg.Format, 0)
+	PrintKeyValue("Platform", cfg.Platform, 0)
+	PrintKeyValue("Preset", cfg.Preset, 0)
 	PrintKeyValue("Report", fmt.Sprintf("%t", cfg.Report), 0)
 	PrintKeyValue("Watch Mode", fmt.Sprintf("%t", cfg.Watch), 0)
 	PrintKeyValue("Log Level", cfg.LogLevel, 0)
@@ -231,6 +348,29 @@ func PrintBuildSummary(cfg *config.Config) {
 	PrintDivider()
 }
 
+// PrintResolvedConfig prints every setting of cfg together with where it
+// came from: default, config file location, or flag
+func PrintResolvedConfig(cfg *config.Config) {
+	if cfg.Name != "" {
+		DefaultStyles.Section.Printf("Resolved Configuration [%s]\n", cfg.Name)
+	} else {
+		DefaultStyles.Section.Println("Resolved Configuration")
+	}
+
+	entries := cfg.Entries()
+	width := 0
+	for _, e := range entries {
+		if len(e.Key) > width {
+			width = len(e.Key)
+		}
+	}
+	for _, e := range entries {
+		DefaultStyles.Key.Printf("%s%-*s", IconsDefault.Space, width+1, e.Key+":")
+		DefaultStyles.Value.Printf(" %-20s", config.FormatValue(e.Value))
+		DefaultStyles.Dim.Printf(" (%s)\n", e.Source)
+	}
+}
+
 // PrintBuildResult prints the result of a build operation
 func PrintBuildResult(success bool, message string) {
 	if success {
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/core/analyzer"
+)
+
+// Analyze runs `jspackr analyze`, writing an HTML treemap of the bundle,
+// and returns the process exit code
+func Analyze(args []string) int {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	src := bindMetaFlags(fs)
+	var htmlPath string
+	fs.StringVar(&htmlPath, "html", "jspackr-analyze.html", "Path of the HTML report")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	logger := cli.New("info")
+	cli.PrintTitle()
+
+	spinner := cli.NewSpinner("Analyzing...")
+	spinner.Start()
+	meta, err := src.load()
+	if err != nil {
+		spinner.Stop(false)
+		var problems config.Errors
+		if errors.As(err, &problems) {
+			cli.PrintConfigErrors(problems)
+			return 2
+		}
+		logger.Error("Analyze failed: %v", err)
+		return 1
+	}
+	spinner.Stop(true)
+
+	tree := analyzer.BuildTree(meta)
+	if err := analyzer.WriteHTML(htmlPath, tree, "jspackr analyze"); err != nil {
+		logger.Error("Failed to write %s: %v", htmlPath, err)
+		return 1
+	}
+
+	modules := 0
+	for _, out := range meta.Outputs {
+		modules += len(out.Inputs)
+	}
+	cli.PrintKeyValue("Outputs", fmt.Sprintf("%d", len(tree.Children)), 0)
+	cli.PrintKeyValue("Modules", fmt.Sprintf("%d", modules), 0)
+	cli.PrintKeyValue("Bundled size", fmt.Sprintf("%d bytes", tree.Bytes), 0)
+	logger.Success("Wrote %s", htmlPath)
+
+	if dups := analyzer.FindDuplicates(meta); len(dups) > 0 {
+		analyzer.PrintDuplicates(dups, "", false)
+	}
+	if cycles, truncated := analyzer.FindCycles(meta, nil); len(cycles) > 0 {
+		analyzer.PrintCycles(cycles, truncated, "", false)
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/utils"
+)
+
+// Clean runs `jspackr clean`, emptying the output directory of every
+// target, and returns the process exit code
+func Clean(args []string) int {
+	fs := flag.NewFlagSet("clean", flag.ContinueOnError)
+	flagCfg, configPath, profile := utils.BindConfigFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	utils.MarkFlagOrigins(fs, flagCfg)
+
+	logger := cli.New("info")
+	cli.PrintTitle()
+
+	path, discovered := utils.ConfigPath(*configPath)
+	resolved, err := config.Resolve(path, *profile, discovered, flagCfg)
+	if err != nil {
+		var problems config.Errors
+		if errors.As(err, &problems) {
+			cli.PrintConfigErrors(problems)
+			return 2
+		}
+		logger.Error("Failed to load config: %v", err)
+		return 2
+	}
+
+	cleaned := make(map[string]bool)
+	for _, target := range resolved.Targets {
+		ok, err := CleanOutput(target, resolved.Targets, path, cleaned, logger)
+		if err != nil {
+			logger.Error("%v", err)
+			return 1
+		}
+		if !ok {
+			cli.DefaultStyles.Warn.Println("\n⚠ Clean cancelled")
+			return 1
+		}
+	}
+	return 0
+}
+
+// CleanOutput empties the output directory of target after checking that
+// doing so is safe and asking for confirmation. Directories in cleaned
+// are skipped and the cleaned one is added. It returns false if the user
+// declined.
+func CleanOutput(target *config.Config, targets []*config.Config, configPath string, cleaned map[string]bool, logger *cli.Logger) (bool, error) {
+	dir := utils.GetOutputParent(target.Output)
+	if cleaned[dir] {
+		return true, nil
+	}
+	if err := utils.CheckCleanDir(dir, configPath, targets); err != nil {
+		return false, err
+	}
+	if notEmpty, _ := utils.DirNotEmpty(dir); !notEmpty {
+		cleaned[dir] = true
+		return true, nil
+	}
+	if !utils.ConfirmClean(dir, target.NoConfirm) {
+		return false, nil
+	}
+
+	removed, err := utils.CleanDir(dir)
+	if err != nil {
+		return false, err
+	}
+	cleaned[dir] = true
+	logger.Info("Cleaned %s (%d entries removed)", dir, removed)
+	return true, nil
+}
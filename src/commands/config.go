@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/utils"
+)
+
+// Config runs the `jspackr config <subcommand>` family and returns the
+// process exit code
+func Config(args []string) int {
+	if len(args) == 0 {
+		printConfigUsage()
+		return 2
+	}
+
+	switch args[0] {
+	case "validate":
+		return configValidate(args[1:])
+	case "schema":
+		return configSchema()
+	case "print":
+		return configPrint(args[1:])
+	default:
+		cli.DefaultStyles.Error.Printf("\n%s Unknown config command: %s\n", cli.IconsDefault.Error, args[0])
+		printConfigUsage()
+		return 2
+	}
+}
+
+// printConfigUsage lists the config subcommands
+func printConfigUsage() {
+	fmt.Println()
+	cli.DefaultStyles.Section.Println("Usage: jspackr config <command> [options]")
+	cli.PrintKeyValue("validate", "Check the config file for unknown keys and invalid values", 1)
+	cli.PrintKeyValue("schema", "Print the JSON Schema for config files", 1)
+	cli.PrintKeyValue("print", "Print the resolved config and where each value came from", 1)
+	fmt.Println()
+}
+
+// configFlags holds the options shared by config subcommands
+type configFlags struct {
+	path    string
+	profile string
+}
+
+// parseConfigFlags parses -c/--config and -p/--profile for a config subcommand
+func parseConfigFlags(name string, args []string) (*configFlags, *flag.FlagSet, error) {
+	fs := flag.NewFlagSet("config "+name, flag.ContinueOnError)
+	opts := &configFlags{}
+	fs.StringVar(&opts.path, "c", "", "Path to config file")
+	fs.StringVar(&opts.path, "config", "", "Path to config file")
+	fs.StringVar(&opts.profile, "p", "", "Config profile")
+	fs.StringVar(&opts.profile, "profile", "", "Config profile")
+	return opts, fs, fs.Parse(args)
+}
+
+// findConfig returns the explicit config path or the discovered one,
+// and whether it was discovered
+func (f *configFlags) findConfig() (string, bool, error) {
+	if f.path != "" {
+		return f.path, false, nil
+	}
+	path, err := utils.FindConfigFile()
+	if err != nil {
+		return "", false, err
+	}
+	if path == "" {
+		return "", false, errors.New("no config file found")
+	}
+	return path, true, nil
+}
+
+// configValidate checks a config file in one pass and reports every problem
+func configValidate(args []string) int {
+	opts, _, err := parseConfigFlags("validate", args)
+	if err != nil {
+		return 2
+	}
+
+	path, discovered, err := opts.findConfig()
+	if err != nil {
+		cli.DefaultStyles.Error.Printf("\n%s %v\n", cli.IconsDefault.Error, err)
+		return 2
+	}
+
+	var problems config.Errors
+	if _, err := config.Resolve(path, opts.profile, discovered, nil); err != nil && !errors.As(err, &problems) {
+		cli.DefaultStyles.Error.Printf("\n%s Failed to load config: %v\n", cli.IconsDefault.Error, err)
+		return 2
+	}
+
+	if len(problems) > 0 {
+		cli.PrintConfigErrors(problems)
+		return 1
+	}
+
+	fmt.Println()
+	cli.DefaultStyles.Value.Printf("%s %s is valid\n", cli.IconsDefault.Success, path)
+	return 0
+}
+
+// configSchema prints the JSON Schema generated from the Config struct
+func configSchema() int {
+	data, err := config.SchemaJSON()
+	if err != nil {
+		cli.DefaultStyles.Error.Printf("\n%s %v\n", cli.IconsDefault.Error, err)
+		return 1
+	}
+	os.Stdout.Write(data)
+	return 0
+}
+
+// configPrint prints the final merged config, including build flags
+// passed after the subcommand, with the source of every value
+func configPrint(args []string) int {
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	flagCfg, configPath, profile := utils.BindConfigFlags(fs)
+	var asJSON bool
+	fs.BoolVar(&asJSON, "json", false, "Print as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	utils.MarkFlagOrigins(fs, flagCfg)
+
+	path, discovered := utils.ConfigPath(*configPath)
+
+	var problems config.Errors
+	resolved, err := config.Resolve(path, *profile, discovered, flagCfg)
+	if err != nil && !errors.As(err, &problems) {
+		cli.DefaultStyles.Error.Printf("\n%s Failed to load config: %v\n", cli.IconsDefault.Error, err)
+		return 2
+	}
+
+	targets := resolved.Targets
+	if len(targets) == 1 {
+		targets[0].Name = resolved.Root.Name
+	}
+
+	if asJSON {
+		if err := printConfigJSON(resolved.Path, targets); err != nil {
+			cli.DefaultStyles.Error.Printf("\n%s %v\n", cli.IconsDefault.Error, err)
+			return 1
+		}
+	} else {
+		fmt.Println()
+		if resolved.Path != "" {
+			cli.PrintConfigSource(resolved.Path)
+		} else {
+			cli.PrintHelpInfo("No config file found, showing defaults and flags")
+			fmt.Println()
+		}
+		for _, target := range targets {
+			cli.PrintResolvedConfig(target)
+			fmt.Println()
+		}
+	}
+
+	if len(problems) > 0 {
+		cli.PrintConfigErrors(problems)
+		return 1
+	}
+	return 0
+}
+
+// printConfigJSON prints the resolved targets as JSON, each key mapped
+// to its value and source
+func printConfigJSON(path string, targets []*config.Config) error {
+	type value struct {
+		Value  any    `json:"value"`
+		Source string `json:"source"`
+	}
+	out := struct {
+		Config  string             `json:"config"`
+		Targets []map[string]value `json:"targets"`
+	}{Config: path}
+
+	for _, target := range targets {
+		values := make(map[string]value)
+		for _, e := range target.Entries() {
+			values[e.Key] = value{Value: e.Value, Source: e.Source}
+		}
+		out.Targets = append(out.Targets, values)
+	}
+
+	data, err := json.MarshalIndent(out, "", "\t")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
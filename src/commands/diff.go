@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/core/analyzer"
+	"github.com/kalokaradia/jspackr/src/core/builder"
+)
+
+// DefaultBaseline is where `jspackr diff --save` keeps the baseline
+const DefaultBaseline = ".jspackr/baseline.json"
+
+// Diff runs `jspackr diff`, comparing the current build with a baseline,
+// and returns the process exit code
+func Diff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	src := bindMetaFlags(fs)
+	var basePath string
+	var save bool
+	var limit int
+	fs.StringVar(&basePath, "base", DefaultBaseline, "Baseline stats, history file or metafile to compare against")
+	fs.BoolVar(&save, "save", false, "Save the current build as the baseline")
+	fs.IntVar(&limit, "limit", 20, "Number of changed modules to list (0 for all)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	logger := cli.New("info")
+	cli.PrintTitle()
+
+	spinner := cli.NewSpinner("Building...")
+	spinner.Start()
+	meta, err := src.load()
+	if err != nil {
+		spinner.Stop(false)
+		var problems config.Errors
+		if errors.As(err, &problems) {
+			cli.PrintConfigErrors(problems)
+			return 2
+		}
+		logger.Error("Build failed: %v", err)
+		return 1
+	}
+	spinner.Stop(true)
+	current := builder.StatsFromMeta(meta)
+
+	if save {
+		if err := builder.WriteStats(basePath, current); err != nil {
+			logger.Error("Failed to save baseline: %v", err)
+			return 1
+		}
+		logger.Success("Saved baseline to %s", basePath)
+		return 0
+	}
+
+	base, err := builder.LoadStats(basePath)
+	if err != nil {
+		logger.WarnWithTip(
+			fmt.Sprintf("Cannot read baseline: %v", err),
+			"Run `jspackr diff --save` on the base branch, or pass --base <file>",
+		)
+		return 1
+	}
+
+	cli.DefaultStyles.Key.Printf("  %s Baseline:", cli.IconsDefault.Space)
+	cli.DefaultStyles.Path.Printf(" %s\n", basePath)
+	printComparison(analyzer.Compare(base, current), limit)
+	return 0
+}
+
+// printComparison prints the per-output and per-module size changes
+func printComparison(c *analyzer.Comparison, limit int) {
+	fmt.Println()
+	cli.DefaultStyles.Section.Println("Outputs:")
+	for _, d := range c.Outputs {
+		cli.DefaultStyles.Path.Printf("  %-46s ", d.Path)
+		printDelta(d)
+	}
+
+	fmt.Println()
+	if len(c.Modules) == 0 {
+		cli.DefaultStyles.Dim.Println("  No module changed size")
+	} else {
+		cli.DefaultStyles.Section.Printf("Modules (%d changed):\n", len(c.Modules))
+		for i, d := range c.Modules {
+			if limit > 0 && i == limit {
+				cli.DefaultStyles.Dim.Printf("  ... and %d more (--limit 0 lists all)\n", len(c.Modules)-i)
+				break
+			}
+			cli.DefaultStyles.Dim.Printf("  %-46s ", d.Path)
+			printDelta(d)
+		}
+	}
+
+	fmt.Println()
+	cli.DefaultStyles.Key.Printf("  %s Total:", cli.IconsDefault.Space)
+	printDelta(analyzer.Delta{Before: c.Before, After: c.After})
+	fmt.Println()
+}
+
+// printDelta prints a size change such as "45.2 KB → 85.3 KB  +40.1 KB (+88.7%)"
+func printDelta(d analyzer.Delta) {
+	cli.DefaultStyles.Stats.Printf("%10s → %-10s ", builder.FormatBytes(d.Before), builder.FormatBytes(d.After))
+
+	change := d.Change()
+	style := cli.DefaultStyles.Dim
+	sign := ""
+	switch {
+	case change > 0:
+		style, sign = cli.DefaultStyles.Error, "+"
+	case change < 0:
+		style, sign = cli.DefaultStyles.Value, "-"
+	}
+	style.Printf("%s%s", sign, builder.FormatBytes(d.AbsChange()))
+
+	switch {
+	case d.Added():
+		cli.DefaultStyles.Dim.Println(" (new)")
+	case d.Removed():
+		cli.DefaultStyles.Dim.Println(" (removed)")
+	case d.Before > 0:
+		cli.DefaultStyles.Dim.Printf(" (%+.1f%%)\n", float64(change)/float64(d.Before)*100)
+	default:
+		fmt.Println()
+	}
+}
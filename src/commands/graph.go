@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/core/analyzer"
+)
+
+// graphFormats lists the formats `jspackr graph` can write
+var graphFormats = []string{"dot", "mermaid", "json"}
+
+// graphPackages lists the --packages modes
+var graphPackages = []string{analyzer.PackagesCollapse, analyzer.PackagesShow, analyzer.PackagesHide}
+
+// Graph runs `jspackr graph`, printing the import graph to stdout so it
+// can be piped into other tools, and returns the process exit code
+func Graph(args []string) int {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	src := bindMetaFlags(fs)
+	var opts analyzer.ExportOptions
+	var format string
+	fs.StringVar(&format, "as", "dot", "Graph format: dot, mermaid or json")
+	fs.StringVar(&opts.Packages, "packages", analyzer.PackagesCollapse, "node_modules handling: collapse, show or hide")
+	fs.IntVar(&opts.Depth, "depth", 0, "Maximum import depth from the entry points (0 for all)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// Messages go to stderr to keep stdout clean
+	fail := func(code int, format string, args ...any) int {
+		cli.DefaultStyles.Error.Fprintf(os.Stderr, "%s "+format+"\n", append([]any{cli.IconsDefault.Error}, args...)...)
+		return code
+	}
+	if !slices.Contains(graphFormats, format) {
+		return fail(2, "Invalid graph format %q: use dot, mermaid, or json", format)
+	}
+	if !slices.Contains(graphPackages, opts.Packages) {
+		return fail(2, "Invalid packages mode %q: use collapse, show, or hide", opts.Packages)
+	}
+
+	meta, err := src.load()
+	if err != nil {
+		var problems config.Errors
+		if errors.As(err, &problems) {
+			for _, p := range problems {
+				fmt.Fprintln(os.Stderr, p.Error())
+			}
+			return 2
+		}
+		return fail(1, "Failed to build the import graph: %v", err)
+	}
+
+	export := analyzer.NewGraph(meta).Export(opts)
+	switch format {
+	case "mermaid":
+		err = export.WriteMermaid(os.Stdout)
+	case "json":
+		err = export.WriteJSON(os.Stdout)
+	default:
+		err = export.WriteDOT(os.Stdout)
+	}
+	if err != nil {
+		return fail(1, "%v", err)
+	}
+	return 0
+}
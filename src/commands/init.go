@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+)
+
+// initAnswers holds the values collected by the init wizard
+type initAnswers struct {
+	Input     string `json:"input"`
+	Output    string `json:"output"`
+	Format    string `json:"format"`
+	Platform  string `json:"platform"`
+	Preset    string `json:"preset"`
+	SourceMap string `json:"sourcemap"`
+	Minify    bool   `json:"minify"`
+	Starter   bool   `json:"-"`
+}
+
+// defaultAnswers returns the answers used by `init --yes`
+func defaultAnswers() initAnswers {
+	def := config.Default()
+	return initAnswers{
+		Input:     "src/index.js",
+		Output:    def.Output,
+		Format:    def.Format,
+		Platform:  def.Platform,
+		Preset:    def.Preset,
+		SourceMap: def.SourceMap,
+		Starter:   true,
+	}
+}
+
+// Init runs the `jspackr init` wizard and returns the process exit code
+func Init(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	var yes, force bool
+	fs.BoolVar(&yes, "y", false, "Use defaults without prompting")
+	fs.BoolVar(&yes, "yes", false, "Use defaults without prompting")
+	fs.BoolVar(&force, "f", false, "Overwrite an existing config file")
+	fs.BoolVar(&force, "force", false, "Overwrite an existing config file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	logger := cli.New("info")
+	cli.PrintTitle()
+
+	if _, err := os.Stat(config.FileName); err == nil && !force {
+		if yes {
+			logger.Error("%s already exists (use --force to overwrite)", config.FileName)
+			return 1
+		}
+		if !cli.Confirm(config.FileName+" already exists. Overwrite?", false) {
+			cli.DefaultStyles.Warn.Println("\n⚠ Init cancelled")
+			return 0
+		}
+	}
+
+	answers := defaultAnswers()
+	if !yes {
+		answers = askAnswers(answers)
+	}
+
+	if err := writeInitConfig(answers); err != nil {
+		logger.Error("Failed to write %s: %v", config.FileName, err)
+		return 1
+	}
+	logger.Success("Created %s", config.FileName)
+
+	if answers.Starter {
+		if err := writeStarterFiles(answers, logger); err != nil {
+			logger.Error("Failed to write starter files: %v", err)
+			return 1
+		}
+	}
+
+	fmt.Println()
+	cli.PrintHelpInfo("Run `jspackr` to build your project")
+	return 0
+}
+
+// askAnswers runs the interactive prompts, starting from defaults
+func askAnswers(def initAnswers) initAnswers {
+	a := def
+
+	cli.DefaultStyles.Section.Println("Project setup")
+	a.Preset = cli.Select("Framework preset", []string{"vanilla", "react", "preact"}, 0)
+	if a.Preset != "vanilla" {
+		def.Input = "src/index.jsx"
+	}
+	a.Input = cli.Prompt("Entry file", def.Input)
+	a.Output = cli.Prompt("Output file", def.Output)
+	a.Format = cli.Select("Output format", []string{"iife", "esm", "cjs"}, 0)
+	a.Platform = cli.Select("Target platform", []string{"browser", "node", "neutral"}, 0)
+
+	opts := cli.DefaultPromptOptions()
+	opts.Tip = "l = linked .map file, in = inline in the bundle"
+	a.SourceMap = cli.SelectWithOptions("Source maps", []string{"none", "l", "in"}, opts, 0)
+
+	a.Minify = cli.Confirm("Minify output?", false)
+	a.Starter = cli.ConfirmWithOptions("Create starter files?", cli.DefaultPromptOptions(), true)
+	fmt.Println()
+
+	return a
+}
+
+// writeInitConfig writes the wizard answers as jspackr.config.json
+func writeInitConfig(a initAnswers) error {
+	data, err := json.MarshalIndent(a, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(config.FileName, append(data, '\n'), 0644)
+}
+
+// writeStarterFiles creates the entry file and, for browser builds, an
+// index.html that loads the bundle. Existing files are never overwritten.
+func writeStarterFiles(a initAnswers, logger *cli.Logger) error {
+	files := map[string]string{a.Input: starterEntry(a.Preset, a.Platform)}
+	if a.Platform == "browser" {
+		files["index.html"] = starterHTML(a)
+	}
+
+	for _, path := range []string{a.Input, "index.html"} {
+		content, ok := files[path]
+		if !ok {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			logger.Warn("Skipped %s (already exists)", path)
+			continue
+		}
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+		logger.Success("Created %s", path)
+	}
+	return nil
+}
+
+// starterEntry returns the starter entry source for a preset. Only
+// browser builds have a document to render into, so the others get a
+// plain module whatever the preset.
+func starterEntry(preset, platform string) string {
+	if platform != "browser" {
+		return `console.log("Hello from jspackr");
+`
+	}
+	switch preset {
+	case "react":
+		return `import { createRoot } from "react-dom/client";
+
+function App() {
+	return <h1>Hello from jspackr</h1>;
+}
+
+createRoot(document.getElementById("app")).render(<App />);
+`
+	case "preact":
+		return `import { render } from "preact";
+
+function App() {
+	return <h1>Hello from jspackr</h1>;
+}
+
+render(<App />, document.getElementById("app"));
+`
+	default:
+		return `const app = document.getElementById("app");
+
+if (app) {
+	app.textContent = "Hello from jspackr";
+} else {
+	console.log("Hello from jspackr");
+}
+`
+	}
+}
+
+// starterHTML returns an index.html that loads the output bundle
+func starterHTML(a initAnswers) string {
+	script := "./" + filepath.ToSlash(strings.TrimPrefix(filepath.Clean(a.Output), "./"))
+	typeAttr := ""
+	if a.Format == "esm" {
+		typeAttr = ` type="module"`
+	}
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+	<head>
+		<meta charset="UTF-8" />
+		<meta name="viewport" content="width=device-width, initial-scale=1.0" />
+		<title>jspackr app</title>
+	</head>
+	<body>
+		<div id="app"></div>
+		<script%s src="%s"></script>
+	</body>
+</html>
+`, typeAttr, script)
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/core/builder"
+	"github.com/kalokaradia/jspackr/src/utils"
+)
+
+// metaSource provides a metafile for the analysis commands, either from a
+// saved file (--meta) or by building the configured targets in memory
+type metaSource struct {
+	flagCfg    *config.Config
+	configPath *string
+	profile    *string
+	metaPath   string
+	fs         *flag.FlagSet
+}
+
+// bindMetaFlags registers the build flags and --meta on fs
+func bindMetaFlags(fs *flag.FlagSet) *metaSource {
+	src := &metaSource{fs: fs}
+	src.flagCfg, src.configPath, src.profile = utils.BindConfigFlags(fs)
+	fs.StringVar(&src.metaPath, "meta", "", "Read a saved esbuild metafile instead of building")
+	return src
+}
+
+// load returns the metafile, building every target without writing
+// outputs when no saved metafile was given
+func (m *metaSource) load() (*builder.MetaFile, error) {
+	if m.metaPath != "" {
+		return builder.LoadMetafile(m.metaPath)
+	}
+
+	utils.MarkFlagOrigins(m.fs, m.flagCfg)
+	path, discovered := utils.ConfigPath(*m.configPath)
+	resolved, err := config.Resolve(path, *m.profile, discovered, m.flagCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := make([]builder.Options, len(resolved.Targets))
+	for i, target := range resolved.Targets {
+		opts[i] = builder.FromConfig(target)
+		opts[i].Metafile = true
+		opts[i].DryRun = true
+		// Analysis should still work on code that breaks import rules
+		opts[i].Boundaries = nil
+	}
+
+	results, errs := builder.BuildAll(opts)
+	metas := make([]*builder.MetaFile, 0, len(results))
+	for i, result := range results {
+		if errs[i] != nil {
+			return nil, errors.Join(errs...)
+		}
+		meta, err := builder.ParseMetafile(result.Metafile)
+		if err != nil {
+			return nil, err
+		}
+		metas = append(metas, meta)
+	}
+	return builder.MergeMetafiles(metas...), nil
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/core/analyzer"
+)
+
+// listFlag collects the values of a repeatable string flag
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+// Unused runs `jspackr unused`, listing source files no entry point
+// reaches and exports no bundled module imports, and returns the process
+// exit code
+func Unused(args []string) int {
+	fs := flag.NewFlagSet("unused", flag.ContinueOnError)
+	src := bindMetaFlags(fs)
+	var root string
+	var ignore listFlag
+	var filesOnly, exportsOnly bool
+	fs.StringVar(&root, "root", "", "Source directory to check (default: the directory of the entry points)")
+	fs.Var(&ignore, "ignore", "Glob pattern of files to skip (repeatable)")
+	fs.BoolVar(&filesOnly, "files", false, "Only report unused files")
+	fs.BoolVar(&exportsOnly, "exports", false, "Only report unused exports")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	logger := cli.New("info")
+	cli.PrintTitle()
+
+	spinner := cli.NewSpinner("Analyzing...")
+	spinner.Start()
+	meta, err := src.load()
+	if err != nil {
+		spinner.Stop(false)
+		var problems config.Errors
+		if errors.As(err, &problems) {
+			cli.PrintConfigErrors(problems)
+			return 2
+		}
+		logger.Error("Build failed: %v", err)
+		return 1
+	}
+
+	if root == "" {
+		root = analyzer.SourceRoot(meta)
+	}
+	var files []string
+	if !exportsOnly {
+		files, err = analyzer.FindUnusedFiles(meta, root, append(analyzer.DefaultUnusedIgnore, ignore...))
+		if err != nil {
+			spinner.Stop(false)
+			logger.Error("Failed to scan %s: %v", root, err)
+			return 1
+		}
+	}
+	var exports []analyzer.UnusedExport
+	if !filesOnly {
+		exports, err = analyzer.FindUnusedExports(meta)
+		if err != nil {
+			spinner.Stop(false)
+			logger.Error("Failed to read exports: %v", err)
+			return 1
+		}
+	}
+	spinner.Stop(true)
+
+	if !exportsOnly {
+		fmt.Println()
+		if len(files) == 0 {
+			logger.Success("No unused files under %s", root)
+		} else {
+			cli.DefaultStyles.Warn.Printf("%s Unused files under %s (%d):\n", cli.IconsDefault.Warn, root, len(files))
+			for _, f := range files {
+				cli.DefaultStyles.Path.Printf("  %s\n", f)
+			}
+		}
+	}
+
+	if !filesOnly {
+		fmt.Println()
+		if len(exports) == 0 {
+			logger.Success("No unused exports")
+		} else {
+			count := 0
+			for _, e := range exports {
+				count += len(e.Names)
+			}
+			cli.DefaultStyles.Warn.Printf("%s Unused exports (%d in %d modules):\n", cli.IconsDefault.Warn, count, len(exports))
+			for _, e := range exports {
+				cli.DefaultStyles.Path.Printf("  %s", e.Path)
+				cli.DefaultStyles.Dim.Printf(": %s\n", strings.Join(e.Names, ", "))
+			}
+		}
+	}
+	fmt.Println()
+	return 0
+}
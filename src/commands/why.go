@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/core/analyzer"
+)
+
+// Why runs `jspackr why <module-or-package>`, printing every import path
+// from an entry point to the module, and returns the process exit code
+func Why(args []string) int {
+	fs := flag.NewFlagSet("why", flag.ContinueOnError)
+	src := bindMetaFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// Flags may also follow the module name
+	query, extra := fs.Arg(0), 0
+	if fs.NArg() > 1 {
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return 2
+		}
+		extra = fs.NArg()
+	}
+	if query == "" || extra > 0 {
+		fmt.Println()
+		cli.DefaultStyles.Section.Println("Usage: jspackr why <module-or-package> [--meta <metafile>] [build options]")
+		fmt.Println()
+		return 2
+	}
+
+	logger := cli.New("info")
+	cli.PrintTitle()
+
+	meta, err := src.load()
+	if err != nil {
+		var problems config.Errors
+		if errors.As(err, &problems) {
+			cli.PrintConfigErrors(problems)
+			return 2
+		}
+		logger.Error("Build failed: %v", err)
+		return 1
+	}
+
+	graph := analyzer.NewGraph(meta)
+	targets := graph.Targets(query)
+	if len(targets) == 0 {
+		logger.WarnWithTip(
+			fmt.Sprintf("%s is not in the bundle", query),
+			"Pass a module path such as src/util.js or a package name such as lodash",
+		)
+		return 1
+	}
+
+	paths, truncated := graph.ImportPaths(targets)
+	if len(paths) == 0 {
+		logger.Warn("%s is in the bundle but not reachable from an entry point", query)
+		return 1
+	}
+
+	fmt.Println()
+	title := fmt.Sprintf("%d import paths to %s", len(paths), query)
+	switch {
+	case truncated:
+		title = fmt.Sprintf("First %d import paths to %s", len(paths), query)
+	case len(paths) == 1:
+		title = fmt.Sprintf("1 import path to %s", query)
+	}
+	cli.DefaultStyles.Section.Printf("%s:\n", title)
+
+	for i, path := range paths {
+		fmt.Println()
+		cli.DefaultStyles.Dim.Printf("  %d. ", i+1)
+		cli.DefaultStyles.Highlight.Println(path[0].Path)
+		for depth, step := range path[1:] {
+			cli.DefaultStyles.Dim.Printf("  %*s└─ ", depth*3+3, "")
+			if step.Path == path[len(path)-1].Path {
+				cli.DefaultStyles.Path.Print(step.Path)
+			} else {
+				cli.DefaultStyles.Value.Print(step.Path)
+			}
+			cli.DefaultStyles.Stats.Printf(" (%s)\n", analyzer.ImportKind(step.Kind))
+		}
+	}
+	fmt.Println()
+	return 0
+}
@@ -0,0 +1,40 @@
+package config
+
+import "fmt"
+
+// Boundary is an import rule: files matching From may not import files
+// matching Disallow or the packages in DisallowPackages. Paths are globs
+// relative to the config file; a directory also matches everything in it.
+type Boundary struct {
+	From             string   `json:"from" desc:"Glob of importing files the rule applies to; every file when empty"`
+	Disallow         []string `json:"disallow" desc:"Globs of files these files may not import"`
+	DisallowPackages []string `json:"disallowPackages" desc:"Packages these files may not import, such as lodash or @scope/name"`
+	Message          string   `json:"message" desc:"Explanation shown when the rule is broken"`
+}
+
+// validateBoundaries reports rules that cannot match anything
+func validateBoundaries(rules []Boundary) []string {
+	var problems []string
+	for i, rule := range rules {
+		if len(rule.Disallow) == 0 && len(rule.DisallowPackages) == 0 {
+			problems = append(problems, fmt.Sprintf("boundaries[%d]: set disallow or disallowPackages", i))
+		}
+	}
+	return problems
+}
+
+// rebaseBoundaries rewrites the path globs of rules, found at path, with
+// rebase
+func rebaseBoundaries(rules []Boundary, path string, rebase func(key, p string) string) {
+	for i := range rules {
+		rule := fmt.Sprintf("%s[%d]", path, i)
+		if rules[i].From != "" {
+			rules[i].From = rebase(rule+".from", rules[i].From)
+		}
+		disallow := make([]string, len(rules[i].Disallow))
+		for j, pattern := range rules[i].Disallow {
+			disallow[j] = rebase(fmt.Sprintf("%s.disallow[%d]", rule, j), pattern)
+		}
+		rules[i].Disallow = disallow
+	}
+}
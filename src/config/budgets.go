@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Budgets limits the size of the files a build writes. Limits are sizes
+// such as "150kb"; outputs and entries are keyed by path relative to the
+// config file, types by file extension without the dot.
+type Budgets struct {
+	Total   Budget            `json:"total" desc:"Limit for all outputs of the build combined, excluding source maps"`
+	Outputs map[string]Budget `json:"outputs" desc:"Limits per output file, keyed by path"`
+	Entries map[string]Budget `json:"entries" desc:"Limits per entry point, covering every output it produces, keyed by input path"`
+	Types   map[string]Budget `json:"types" desc:"Limits per asset type, keyed by extension such as js or css"`
+	Warn    bool              `json:"warn" desc:"Warn instead of failing the build when a budget is exceeded"`
+	// Baseline keeps the last build within budget to compare against
+	Baseline bool `json:"baseline" desc:"Keep the last build within budget in .jspackr/budgets to list the modules that grew"`
+}
+
+// Budget is a raw and an optional gzip size limit
+type Budget struct {
+	Size string `json:"size" desc:"Maximum raw size, such as 150kb"`
+	Gzip string `json:"gzip" desc:"Maximum gzip size, such as 50kb"`
+}
+
+// Empty reports whether no budget is configured
+func (b Budgets) Empty() bool {
+	return b.Total.Empty() && len(b.Outputs) == 0 && len(b.Entries) == 0 && len(b.Types) == 0
+}
+
+// Empty reports whether the budget sets no limit
+func (b Budget) Empty() bool {
+	return b.Size == "" && b.Gzip == ""
+}
+
+// sizeUnits maps size suffixes to their multiplier
+var sizeUnits = map[string]float64{
+	"":   1,
+	"b":  1,
+	"kb": 1024,
+	"k":  1024,
+	"mb": 1024 * 1024,
+	"m":  1024 * 1024,
+}
+
+// ParseSize parses a size such as "512", "150kb" or "1.5 MB" into bytes.
+// Units are binary, so 1kb is 1024 bytes.
+func ParseSize(s string) (int64, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(str)
+	}
+	num, unit := str[:i], strings.TrimSpace(str[i:])
+
+	n, err := strconv.ParseFloat(num, 64)
+	mult, ok := sizeUnits[unit]
+	if err != nil || !ok || n < 0 {
+		return 0, fmt.Errorf("invalid size %q: use a number of bytes or a value such as 150kb or 1.5mb", s)
+	}
+	return int64(n * mult), nil
+}
+
+// validateBudgets reports every budget with a size that does not parse
+func validateBudgets(b Budgets) []string {
+	var problems []string
+	check := func(path string, budget Budget) {
+		for _, limit := range []struct{ key, value string }{{"size", budget.Size}, {"gzip", budget.Gzip}} {
+			if limit.value == "" {
+				continue
+			}
+			if _, err := ParseSize(limit.value); err != nil {
+				problems = append(problems, fmt.Sprintf("budgets.%s.%s: %v", path, limit.key, err))
+			}
+		}
+	}
+
+	check("total", b.Total)
+	for _, group := range []struct {
+		name    string
+		budgets map[string]Budget
+	}{{"outputs", b.Outputs}, {"entries", b.Entries}, {"types", b.Types}} {
+		keys := make([]string, 0, len(group.budgets))
+		for key := range group.budgets {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			check(group.name+"."+key, group.budgets[key])
+		}
+	}
+	return problems
+}
+
+// rebaseBudgets rewrites the output and entry keys of b, found at path,
+// with rebase
+func rebaseBudgets(b *Budgets, path string, rebase func(key, p string) string) {
+	for _, group := range []struct {
+		name string
+		m    *map[string]Budget
+	}{{"outputs", &b.Outputs}, {"entries", &b.Entries}} {
+		if len(*group.m) == 0 {
+			continue
+		}
+		rebased := make(map[string]Budget, len(*group.m))
+		for key, budget := range *group.m {
+			rebased[filepath.Clean(rebase(path+"."+group.name+"."+key, key))] = budget
+		}
+		*group.m = rebased
+	}
+}
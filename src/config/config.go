@@ -1,25 +1,157 @@
 package config
 
-// Config represents the jspackr configuration
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FileName is the default config file name
+const FileName = "jspackr.config.json"
+
+// Config represents the jspackr configuration.
+// The desc and enum tags document each key and feed both the generated
+// JSON Schema and validation, so keep them in sync with the behaviour.
 type Config struct {
-	Input     string `json:"input"`
-	Output    string `json:"output"`
-	Minify    bool   `json:"minify"`
-	Report    bool   `json:"report"`
-	SourceMap string `json:"sourcemap"`
-	Watch     bool   `json:"watch"`
-	LogLevel  string `json:"logLevel"`
+	Name      string `json:"name" desc:"Label for this build in reports"`
+	Input     string `json:"input" desc:"Entry file to bundle"`
+	Output    string `json:"output" desc:"Output bundle file"`
+	Minify    bool   `json:"minify" desc:"Minify the output bundle"`
+	Report    bool   `json:"report" desc:"Print a build report"`
+	SourceMap string `json:"sourcemap" desc:"Source map mode: none, l (linked .map file) or in (inline)" enum:"none,l,in"`
+	Watch     bool   `json:"watch" desc:"Rebuild when the entry file changes"`
+	LogLevel  string `json:"logLevel" desc:"Log verbosity" enum:"debug,info,warn,error"`
+	Format    string `json:"format" desc:"Output format" enum:"iife,esm,cjs"`
+	Platform  string `json:"platform" desc:"Target platform" enum:"browser,node,neutral"`
+	Preset    string `json:"preset" desc:"Framework preset, controls JSX handling" enum:"vanilla,react,preact"`
+	Compress  bool   `json:"compress" desc:"Write precompressed .gz and .br files next to each output"`
+	History   string `json:"history" desc:"Append the stats of every build to this JSON lines file"`
+	Clean     bool   `json:"clean" desc:"Empty the output directory before building"`
 	// Force flags for non-interactive mode
-	Force     bool `json:"force"`     // Skip overwrite confirmation
-	Yes       bool `json:"yes"`       // Auto-confirm overwrite
-	NoConfirm bool `json:"noConfirm"` // Skip all confirmations
+	Force     bool `json:"force" desc:"Skip overwrite confirmation"`
+	Yes       bool `json:"yes" desc:"Auto-confirm overwrite"`
+	NoConfirm bool `json:"noConfirm" desc:"Skip all confirmations"`
+	// Builds lists additional build targets. Each target inherits the
+	// top-level settings and overrides them with its own values.
+	Builds []Config `json:"builds" env:"-" desc:"Additional build targets; each inherits the top-level settings and overrides them"`
+	// Checks run after every build
+	Duplicates  string   `json:"duplicates" desc:"Report packages bundled from more than one location; error fails the build" enum:"off,warn,error"`
+	Cycles      string   `json:"cycles" desc:"Report import cycles among first-party modules; error fails the build" enum:"off,warn,error"`
+	AllowCycles []string `json:"allowCycles" desc:"Glob patterns of modules whose import cycles are ignored"`
+	// Boundaries are import rules enforced while bundling
+	Boundaries []Boundary `json:"boundaries" desc:"Import rules that fail the build when a file imports something it may not"`
+	// Budgets limits output sizes; see Budgets
+	Budgets Budgets `json:"budgets" desc:"Size limits that fail the build when exceeded"`
+	// Plugins are looked up by name among the plugins compiled in
+	Plugins []PluginConfig `json:"plugins" env:"-" desc:"Plugins to run during the build, by registered name"`
+	// Virtual maps import specifiers to generated modules
+	Virtual map[string]VirtualModule `json:"virtual" env:"-" desc:"Modules generated at build time, keyed by import specifier such as virtual:build-info"`
+	// PublicDir is copied as is next to the bundle
+	PublicDir string `json:"publicDir" desc:"Directory copied unchanged into the output directory; empty to disable"`
+	// Manifest lists the bundle outputs and copied public files
+	Manifest string `json:"manifest" desc:"Write a JSON manifest of the files each build wrote to this path"`
+	// Origins records keys that were set explicitly (in a file or on the
+	// command line) and where, so that false values can override true
+	// ones on merge and errors can point at the offending line
+	Origins map[string]Origin `json:"-"`
+}
+
+// Origin kinds; see also OriginEnv
+const (
+	OriginFile = "file"
+	OriginFlag = "flag"
+)
+
+// Origin describes where a config value was set. For file origins, Line
+// and Column are 1-based, or 0 if the format has no position information.
+// Name holds the flag or environment variable name for other origins.
+type Origin struct {
+	Kind   string
+	Name   string
+	File   string
+	Line   int
+	Column int
+}
+
+// String formats the file location as file:line:column, or "" if the
+// value did not come from a file
+func (o Origin) String() string {
+	switch {
+	case o.File == "":
+		return ""
+	case o.Line == 0:
+		return o.File
+	default:
+		return fmt.Sprintf("%s:%d:%d", o.File, o.Line, o.Column)
+	}
+}
+
+// Describe returns a short human readable description of the origin
+func (o Origin) Describe() string {
+	switch {
+	case o.File != "":
+		return o.String()
+	case o.Name != "":
+		return o.Kind + " " + o.Name
+	default:
+		return o.Kind
+	}
+}
+
+// MarkSet records key as explicitly set
+func (c *Config) MarkSet(key string) {
+	if !c.IsSet(key) {
+		c.SetOrigin(key, Origin{})
+	}
+}
+
+// SetOrigin records key as explicitly set at origin
+func (c *Config) SetOrigin(key string, origin Origin) {
+	if c.Origins == nil {
+		c.Origins = make(map[string]Origin)
+	}
+	c.Origins[strings.ToLower(key)] = origin
+}
+
+// IsSet reports whether key was explicitly set
+func (c *Config) IsSet(key string) bool {
+	_, ok := c.Origins[strings.ToLower(key)]
+	return ok
+}
+
+// OriginOf returns where key was set
+func (c *Config) OriginOf(key string) Origin {
+	return c.Origins[strings.ToLower(key)]
+}
+
+// UnmarshalJSON decodes the config and records which keys were present
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	for key := range keys {
+		c.SetOrigin(key, Origin{Kind: OriginFile})
+	}
+	return nil
 }
 
 // Default returns the default configuration
 func Default() *Config {
 	return &Config{
-		Output:    "dist/bundle.js",
-		SourceMap: "none",
-		LogLevel:  "info",
+		Output:     "dist/bundle.js",
+		SourceMap:  "none",
+		LogLevel:   "info",
+		Format:     "iife",
+		Platform:   "browser",
+		Preset:     "vanilla",
+		Duplicates: "off",
+		Cycles:     "off",
+		PublicDir:  "public",
 	}
 }
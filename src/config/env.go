@@ -0,0 +1,101 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix is the prefix of environment variables that override config keys
+const EnvPrefix = "JSPACKR_"
+
+// OriginEnv marks values set from environment variables
+const OriginEnv = "env"
+
+// EnvName returns the environment variable for a config key,
+// e.g. logLevel becomes JSPACKR_LOG_LEVEL
+func EnvName(key string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for i, r := range key {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// FromEnv builds an override config from JSPACKR_* environment variables.
+// Every field with a JSON key is covered unless tagged env:"-". Scalars
+// are parsed from their text form, other values are decoded as JSON.
+// Values that cannot be parsed are reported as Errors and skipped.
+func FromEnv() (*Config, error) {
+	cfg := &Config{}
+	v := reflect.ValueOf(cfg).Elem()
+
+	var errs Errors
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		name := jsonName(field)
+		if name == "" || field.Tag.Get("env") == "-" {
+			continue
+		}
+
+		envName := EnvName(name)
+		raw, ok := os.LookupEnv(envName)
+		if !ok {
+			continue
+		}
+
+		origin := Origin{Kind: OriginEnv, Name: envName}
+		if err := setFromString(v.Field(i), raw); err != nil {
+			errs = append(errs, &Error{Origin: origin, Message: fmt.Sprintf("%s: %v", envName, err)})
+			continue
+		}
+		cfg.SetOrigin(name, origin)
+	}
+
+	return cfg, errs.errorOrNil()
+}
+
+// setFromString parses raw into the field value
+func setFromString(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetFloat(f)
+	default:
+		if err := json.Unmarshal([]byte(raw), field.Addr().Interface()); err != nil {
+			return fmt.Errorf("invalid JSON value: %v", err)
+		}
+	}
+	return nil
+}
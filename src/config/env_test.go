@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	for key, want := range map[string]string{
+		"minify":      "JSPACKR_MINIFY",
+		"logLevel":    "JSPACKR_LOG_LEVEL",
+		"allowCycles": "JSPACKR_ALLOW_CYCLES",
+		"sourcemap":   "JSPACKR_SOURCEMAP",
+	} {
+		if got := EnvName(key); got != want {
+			t.Errorf("EnvName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("JSPACKR_MINIFY", "true")
+	t.Setenv("JSPACKR_WATCH", "0")
+	t.Setenv("JSPACKR_LOG_LEVEL", "debug")
+	t.Setenv("JSPACKR_ALLOW_CYCLES", `["src/a.js", "src/b/**"]`)
+	t.Setenv("JSPACKR_BUDGETS", `{"outputs": {"dist/app.js": {"size": "100kb"}}}`)
+	t.Setenv("JSPACKR_BUILDS", `[{"input": "ignored.js"}]`)
+
+	cfg, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Minify || cfg.Watch || cfg.LogLevel != "debug" {
+		t.Errorf("minify, watch, logLevel = %v, %v, %q, want true, false, debug", cfg.Minify, cfg.Watch, cfg.LogLevel)
+	}
+	if !cfg.IsSet("watch") {
+		t.Error("watch is not marked as set, so its false would not override a file")
+	}
+	if origin := cfg.OriginOf("minify"); origin.Kind != OriginEnv || origin.Name != "JSPACKR_MINIFY" {
+		t.Errorf("minify origin = %+v, want the JSPACKR_MINIFY variable", origin)
+	}
+	if want := []string{"src/a.js", "src/b/**"}; !reflect.DeepEqual(cfg.AllowCycles, want) {
+		t.Errorf("allowCycles = %q, want %q", cfg.AllowCycles, want)
+	}
+	if got := cfg.Budgets.Outputs["dist/app.js"].Size; got != "100kb" {
+		t.Errorf("budgets.outputs[dist/app.js].size = %q, want 100kb", got)
+	}
+	if cfg.Builds != nil {
+		t.Errorf("builds = %+v, want JSPACKR_BUILDS to be ignored", cfg.Builds)
+	}
+}
+
+func TestFromEnvErrors(t *testing.T) {
+	t.Setenv("JSPACKR_MINIFY", "maybe")
+	t.Setenv("JSPACKR_ALLOW_CYCLES", "src/a.js")
+	t.Setenv("JSPACKR_BUDGETS", `{"total": 100}`)
+	t.Setenv("JSPACKR_REPORT", "1")
+
+	cfg, err := FromEnv()
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("FromEnv = %v, want Errors", err)
+	}
+	want := []string{"JSPACKR_MINIFY: invalid boolean", "JSPACKR_ALLOW_CYCLES: invalid JSON value", "JSPACKR_BUDGETS: invalid JSON value"}
+	if len(errs) != len(want) {
+		t.Fatalf("got errors %v, want %d", errs, len(want))
+	}
+	for _, prefix := range want {
+		found := false
+		for _, e := range errs {
+			if strings.HasPrefix(e.Message, prefix) && e.Origin.Kind == OriginEnv {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no error starting with %q in %v", prefix, errs)
+		}
+	}
+
+	// Variables that parse still apply
+	if !cfg.Report || cfg.IsSet("minify") || cfg.AllowCycles != nil {
+		t.Errorf("report, minify set, allowCycles = %v, %v, %q, want only report applied", cfg.Report, cfg.IsSet("minify"), cfg.AllowCycles)
+	}
+}
+
+func TestSetFromString(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any // Pointer to a zero value of the target type
+		raw     string
+		want    any
+		wantErr string
+	}{
+		{name: "string", value: new(string), raw: "dist/app.js", want: "dist/app.js"},
+		{name: "bool", value: new(bool), raw: "TRUE", want: true},
+		{name: "bool error", value: new(bool), raw: "yes", wantErr: `invalid boolean "yes"`},
+		{name: "int", value: new(int), raw: "-42", want: -42},
+		{name: "int error", value: new(int), raw: "4.2", wantErr: `invalid integer "4.2"`},
+		{name: "uint", value: new(uint16), raw: "8080", want: uint16(8080)},
+		{name: "uint error", value: new(uint), raw: "-1", wantErr: `invalid integer "-1"`},
+		{name: "float", value: new(float64), raw: "1.5", want: 1.5},
+		{name: "float error", value: new(float64), raw: "fast", wantErr: `invalid number "fast"`},
+		{name: "list", value: new([]string), raw: `["a", "b"]`, want: []string{"a", "b"}},
+		{name: "list error", value: new([]string), raw: "a,b", wantErr: "invalid JSON value"},
+		{name: "map", value: new(map[string]int), raw: `{"a": 1}`, want: map[string]int{"a": 1}},
+		{name: "map error", value: new(map[string]int), raw: `{"a": "one"}`, wantErr: "invalid JSON value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := reflect.ValueOf(tt.value).Elem()
+			err := setFromString(field, tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("setFromString(%q) = %v, want %q", tt.raw, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setFromString(%q): %v", tt.raw, err)
+			}
+			if got := field.Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("setFromString(%q) = %#v, want %#v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
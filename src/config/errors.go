@@ -0,0 +1,89 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// Error is a single configuration problem, optionally located in a file
+type Error struct {
+	Origin  Origin
+	Message string
+}
+
+// Error formats the problem as file:line:column: message
+func (e *Error) Error() string {
+	if loc := e.Origin.String(); loc != "" {
+		return loc + ": " + e.Message
+	}
+	return e.Message
+}
+
+// Errors collects every problem found in a configuration
+type Errors []*Error
+
+// Error joins all problems, one per line
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Sort orders problems by file and position. Problems without a
+// location keep their relative order and come first.
+func (e Errors) Sort() {
+	sort.SliceStable(e, func(i, j int) bool {
+		a, b := e[i].Origin, e[j].Origin
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// errorOrNil returns errs as an error, or nil if it is empty
+func (e Errors) errorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// suggest returns the candidate closest to name, or "" if none is close
+// enough to be a likely typo
+func suggest(name string, candidates []string) string {
+	best, bestDist := "", len(name)/3+2
+	for _, c := range candidates {
+		d := levenshtein(strings.ToLower(name), strings.ToLower(c))
+		if d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames lists the config file names looked up in each directory,
+// in order of preference
+var FileNames = []string{
+	FileName,
+	"jspackr.config.jsonc",
+	"jspackr.config.yaml",
+	"jspackr.config.yml",
+	"jspackr.config.toml",
+}
+
+// PackageKey is the package.json key that may hold the configuration
+const PackageKey = "jspackr"
+
+// parseFile decodes config file data into a generic map based on the
+// file extension. For package.json only the "jspackr" key is used.
+func parseFile(path string, data []byte) (map[string]any, error) {
+	var raw map[string]any
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonc":
+		if err := json.Unmarshal(StripJSONC(data), &raw); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	}
+
+	if filepath.Base(path) == "package.json" {
+		section, ok := raw[PackageKey].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("no %q object in package.json", PackageKey)
+		}
+		raw = section
+	}
+
+	if raw == nil {
+		raw = make(map[string]any)
+	}
+	return raw, nil
+}
+
+// StripJSONC turns JSON with comments and trailing commas into plain JSON.
+// Removed characters are replaced by spaces so that line and column
+// positions in error messages still match the original file.
+func StripJSONC(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	inString := false
+	lastComma := -1
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			lastComma = -1
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			for i += 2; i < len(out); i++ {
+				if out[i] == '*' && i+1 < len(out) && out[i+1] == '/' {
+					out[i], out[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				out[lastComma] = ' '
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+
+	return out
+}
@@ -2,12 +2,52 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
-// Load loads configuration from a JSON file
+// rawConfig is a parsed config file before decoding into Config,
+// together with the position of every key
+type rawConfig struct {
+	values  map[string]any
+	origins map[string]Origin
+}
+
+// Load loads configuration from a JSON, JSONC, YAML or TOML file, or
+// from the "jspackr" key of a package.json
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	return LoadProfile(path, "", false)
+}
+
+// LoadProfile loads configuration from a file, resolving "extends"
+// chains and applying the named profile on top. An empty profile only
+// resolves the file and its bases. When discovered is set, the file was
+// found by FindConfigFile and relative paths are resolved against the
+// directory of the file that declares them; otherwise they stay relative
+// to the current directory.
+//
+// Unknown keys and values of the wrong type are reported as Errors. In
+// that case the returned config is still usable, with the offending keys
+// left out, so that callers can report validation problems in one pass.
+func LoadProfile(path, profile string, discovered bool) (*Config, error) {
+	var problems Errors
+	raw, err := loadRaw(path, make(map[string]bool), &problems)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile != "" {
+		if err := applyProfile(raw, profile); err != nil {
+			return nil, err
+		}
+	}
+	delete(raw.values, "profiles")
+
+	data, err := json.Marshal(raw.values)
 	if err != nil {
 		return nil, err
 	}
@@ -16,6 +56,239 @@ func Load(path string) (*Config, error) {
 	if err := json.Unmarshal(data, cfg); err != nil {
 		return nil, err
 	}
+	applyOrigins(cfg, raw.origins)
+
+	if discovered {
+		rebasePaths(cfg, raw.origins, filepath.Dir(path))
+	}
+
+	problems.Sort()
+	return cfg, problems.errorOrNil()
+}
+
+// loadRaw reads a config file into a generic map and deep-merges it on top
+// of the files listed in its "extends" key. Paths in "extends" are relative
+// to the file that declares them. Strict check problems are appended to
+// problems; only errors that prevent loading are returned.
+func loadRaw(path string, seen map[string]bool, problems *Errors) (rawConfig, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return rawConfig{}, err
+	}
+	if seen[abs] {
+		return rawConfig{}, fmt.Errorf("circular extends: %s", path)
+	}
+	seen[abs] = true
+	defer delete(seen, abs)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rawConfig{}, err
+	}
+
+	values, err := parseFile(path, data)
+	if err != nil {
+		return rawConfig{}, syntaxError(path, data, err)
+	}
+	origins := locate(path, data, values)
+
+	bases, err := extendsList(values["extends"])
+	if err != nil {
+		*problems = append(*problems, &Error{Origin: origins["extends"], Message: err.Error()})
+	}
+	delete(values, "extends")
+	*problems = append(*problems, checkRaw(values, origins)...)
+
+	merged := rawConfig{values: make(map[string]any), origins: make(map[string]Origin)}
+	for _, base := range bases {
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(path), base)
+		}
+		baseRaw, err := loadRaw(base, seen, problems)
+		if err != nil {
+			return rawConfig{}, err
+		}
+		deepMerge(merged, merged.values, baseRaw.values, baseRaw.origins, "", "")
+	}
+	deepMerge(merged, merged.values, values, origins, "", "")
+
+	return merged, nil
+}
+
+// syntaxError adds the file name, and the position when known, to a
+// parse error
+func syntaxError(path string, data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		if strings.ToLower(filepath.Ext(path)) == ".jsonc" {
+			data = StripJSONC(data)
+		}
+		offset := int(syntaxErr.Offset)
+		if offset > len(data) {
+			offset = len(data)
+		}
+		line := strings.Count(string(data[:offset]), "\n") + 1
+		col := offset - strings.LastIndex(string(data[:offset]), "\n")
+		return &Error{Origin: Origin{File: path, Line: line, Column: col}, Message: err.Error()}
+	}
+	return &Error{Origin: Origin{File: path}, Message: err.Error()}
+}
+
+// extendsList normalizes the "extends" value, which may be a string or
+// an array of strings
+func extendsList(v any) ([]string, error) {
+	switch ext := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{ext}, nil
+	case []any:
+		list := make([]string, 0, len(ext))
+		for _, item := range ext {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("extends must be a string or an array of strings")
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("extends must be a string or an array of strings")
+	}
+}
+
+// applyProfile deep-merges the named entry of the "profiles" map into raw
+func applyProfile(raw rawConfig, profile string) error {
+	profiles, _ := raw.values["profiles"].(map[string]any)
+	selected, ok := profiles[profile].(map[string]any)
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("unknown profile %q: config defines no profiles", profile)
+		}
+		return fmt.Errorf("unknown profile %q (available: %s)", profile, strings.Join(names, ", "))
+	}
+
+	deepMerge(raw, raw.values, selected, raw.origins, "", joinPath("profiles", profile))
+	return nil
+}
+
+// deepMerge merges src into dst. Nested objects are merged key by key,
+// any other value (including arrays and false) replaces the one in dst.
+// target is the object inside dst found at dstPath; the origins of src,
+// found under srcPath, are carried over to dstPath.
+func deepMerge(dst rawConfig, target, src map[string]any, srcOrigins map[string]Origin, dstPath, srcPath string) {
+	for key, value := range src {
+		dp, sp := joinPath(dstPath, key), joinPath(srcPath, key)
+		srcMap, srcIsMap := value.(map[string]any)
+		_, dstIsMap := target[key].(map[string]any)
+
+		if !(srcIsMap && dstIsMap) {
+			dropOrigins(dst.origins, dp)
+			if srcIsMap {
+				target[key] = make(map[string]any)
+			} else {
+				target[key] = value
+				copyOrigins(dst.origins, srcOrigins, dp, sp)
+			}
+		}
+		if origin, ok := srcOrigins[sp]; ok {
+			dst.origins[dp] = origin
+		}
+		if srcIsMap {
+			deepMerge(dst, target[key].(map[string]any), srcMap, srcOrigins, dp, sp)
+		}
+	}
+}
+
+// dropOrigins removes the origins of path and everything below it
+func dropOrigins(origins map[string]Origin, path string) {
+	for key := range origins {
+		if key == path || strings.HasPrefix(key, path+".") || strings.HasPrefix(key, path+"[") {
+			delete(origins, key)
+		}
+	}
+}
+
+// copyOrigins copies the origins of srcPath and everything below it to dstPath
+func copyOrigins(dst, src map[string]Origin, dstPath, srcPath string) {
+	for key, origin := range src {
+		if key == srcPath || strings.HasPrefix(key, srcPath+".") || strings.HasPrefix(key, srcPath+"[") {
+			dst[dstPath+strings.TrimPrefix(key, srcPath)] = origin
+		}
+	}
+}
+
+// applyOrigins records the file positions of top-level and per-build keys
+func applyOrigins(cfg *Config, origins map[string]Origin) {
+	fields := jsonFields(configType)
+	for path, origin := range origins {
+		if _, ok := fields[strings.ToLower(path)]; ok {
+			cfg.SetOrigin(path, origin)
+			continue
+		}
+
+		var i int
+		if n, _ := fmt.Sscanf(path, "builds[%d]", &i); n != 1 || i >= len(cfg.Builds) {
+			continue
+		}
+		if _, key, ok := strings.Cut(path, "]."); ok {
+			if _, known := fields[strings.ToLower(key)]; known {
+				cfg.Builds[i].SetOrigin(key, origin)
+			}
+		}
+	}
+}
+
+// rebasePaths joins the relative paths of cfg and its builds to the
+// directory of the config file that declared them, found through
+// origins. Values no file set, such as defaults, are joined to dir.
+func rebasePaths(cfg *Config, origins map[string]Origin, dir string) {
+	rebase := func(key, p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		base := dir
+		if origin, ok := originAt(origins, key); ok {
+			base = filepath.Dir(origin.File)
+		}
+		return filepath.Join(base, p)
+	}
+	rebaseConfig(cfg, "", rebase)
+	for i := range cfg.Builds {
+		rebaseConfig(&cfg.Builds[i], fmt.Sprintf("builds[%d]", i), rebase)
+	}
+}
+
+// rebaseConfig rebases the paths of a single target found at path
+func rebaseConfig(cfg *Config, path string, rebase func(key, p string) string) {
+	cfg.Input = rebase(joinPath(path, "input"), cfg.Input)
+	cfg.Output = rebase(joinPath(path, "output"), cfg.Output)
+	cfg.History = rebase(joinPath(path, "history"), cfg.History)
+	cfg.PublicDir = rebase(joinPath(path, "publicDir"), cfg.PublicDir)
+	cfg.Manifest = rebase(joinPath(path, "manifest"), cfg.Manifest)
+	rebaseBudgets(&cfg.Budgets, joinPath(path, "budgets"), rebase)
+	rebaseBoundaries(cfg.Boundaries, joinPath(path, "boundaries"), rebase)
+	rebasePlugins(cfg.Plugins, joinPath(path, "plugins"), rebase)
+	rebaseVirtual(cfg.Virtual, joinPath(path, "virtual"), rebase)
+}
 
-	return cfg, nil
+// originAt returns the file origin of key, or of the closest enclosing
+// key that has one
+func originAt(origins map[string]Origin, key string) (Origin, bool) {
+	for key != "" {
+		if origin, ok := origins[key]; ok && origin.File != "" {
+			return origin, true
+		}
+		i := strings.LastIndexAny(key, ".[")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return Origin{}, false
 }
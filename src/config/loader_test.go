@@ -0,0 +1,158 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadExtends(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "shared/base.json", `{"format": "esm", "minify": true, "allowCycles": ["a.js"], "budgets": {"total": {"size": "100kb"}}}`)
+	writeConfig(t, dir, "shared/node.yaml", "extends: base.json\nplatform: node\nbudgets:\n  total:\n    gzip: 30kb\n")
+	path := writeConfig(t, dir, "jspackr.config.json", `{
+  "extends": ["./shared/node.yaml"],
+  "input": "src/index.js",
+  "minify": false,
+  "allowCycles": ["b.js"]
+}`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Format != "esm" || cfg.Platform != "node" || cfg.Input != "src/index.js" {
+		t.Errorf("format, platform, input = %q, %q, %q, want esm, node, src/index.js", cfg.Format, cfg.Platform, cfg.Input)
+	}
+	if cfg.Minify {
+		t.Error("minify = true, want the explicit false to override the inherited true")
+	}
+	if !cfg.IsSet("minify") {
+		t.Error("minify is not marked as set")
+	}
+	if want := []string{"b.js"}; !reflect.DeepEqual(cfg.AllowCycles, want) {
+		t.Errorf("allowCycles = %q, want %q: arrays replace rather than merge", cfg.AllowCycles, want)
+	}
+	if want := (Budget{Size: "100kb", Gzip: "30kb"}); cfg.Budgets.Total != want {
+		t.Errorf("budgets.total = %+v, want %+v: objects merge key by key", cfg.Budgets.Total, want)
+	}
+	if origin := cfg.OriginOf("format"); origin.File != filepath.Join(dir, "shared", "base.json") {
+		t.Errorf("format comes from %q, want the base file", origin.File)
+	}
+}
+
+func TestLoadExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "self",
+			files: map[string]string{"jspackr.config.json": `{"extends": "jspackr.config.json"}`},
+			want:  "circular extends",
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"jspackr.config.json": `{"extends": "a.json"}`,
+				"a.json":              `{"extends": "b.json"}`,
+				"b.json":              `{"extends": "a.json"}`,
+			},
+			want: "circular extends",
+		},
+		{
+			name:  "missing base",
+			files: map[string]string{"jspackr.config.json": `{"extends": "missing.json"}`},
+			want:  "missing.json",
+		},
+		{
+			name:  "not a string",
+			files: map[string]string{"jspackr.config.json": `{"extends": 3}`},
+			want:  "extends must be a string or an array of strings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeConfig(t, dir, name, content)
+			}
+			_, err := Load(filepath.Join(dir, "jspackr.config.json"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "base.json", `{"profiles": {"prod": {"sourcemap": "l"}}}`)
+	path := writeConfig(t, dir, "jspackr.config.json", `{
+  "extends": "base.json",
+  "input": "src/index.js",
+  "minify": true,
+  "budgets": {"total": {"size": "100kb"}},
+  "profiles": {
+    "dev": {"minify": false, "budgets": {"total": {"gzip": "30kb"}}}
+  }
+}`)
+
+	tests := []struct {
+		profile   string
+		minify    bool
+		sourceMap string
+		total     Budget
+		wantErr   string
+	}{
+		{profile: "", minify: true, sourceMap: "none", total: Budget{Size: "100kb"}},
+		{profile: "dev", minify: false, sourceMap: "none", total: Budget{Size: "100kb", Gzip: "30kb"}},
+		{profile: "prod", minify: true, sourceMap: "l", total: Budget{Size: "100kb"}},
+		{profile: "staging", wantErr: `unknown profile "staging" (available: dev, prod)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			cfg, err := LoadProfile(path, tt.profile, false)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("LoadProfile = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Minify != tt.minify || cfg.SourceMap != tt.sourceMap || cfg.Budgets.Total != tt.total {
+				t.Errorf("minify, sourcemap, total = %v, %q, %+v, want %v, %q, %+v",
+					cfg.Minify, cfg.SourceMap, cfg.Budgets.Total, tt.minify, tt.sourceMap, tt.total)
+			}
+		})
+	}
+}
+
+func TestTargetsExplicitFalse(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "jspackr.config.json", `{
+  "input": "src/index.js",
+  "minify": true,
+  "builds": [
+    {"output": "dist/a.js"},
+    {"output": "dist/b.js", "minify": false}
+  ]
+}`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := Targets(cfg)
+	if len(targets) != 2 {
+		t.Fatalf("got %d targets, want 2", len(targets))
+	}
+	if !targets[0].Minify {
+		t.Error("build 1 minify = false, want it inherited from the top level")
+	}
+	if targets[1].Minify {
+		t.Error("build 2 minify = true, want its explicit false to win")
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// locate maps every key path in a config file to its position. Paths use
+// dots for object keys and [i] for array elements, e.g. "builds[0].minify".
+// Formats without position information only get the file name.
+func locate(path string, data []byte, raw map[string]any) map[string]Origin {
+	origins := make(map[string]Origin)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		locateJSON(path, data, origins)
+	case ".jsonc":
+		locateJSON(path, StripJSONC(data), origins)
+	case ".yaml", ".yml":
+		locateYAML(path, data, origins)
+	}
+
+	if filepath.Base(path) == "package.json" {
+		// Only keys inside the "jspackr" object are config keys
+		prefix := PackageKey + "."
+		inner := make(map[string]Origin)
+		for key, origin := range origins {
+			if strings.HasPrefix(key, prefix) {
+				inner[strings.TrimPrefix(key, prefix)] = origin
+			}
+		}
+		origins = inner
+	}
+
+	// Fill in keys the format could not locate
+	fillOrigins(raw, "", Origin{File: path}, origins)
+
+	for key, origin := range origins {
+		origin.Kind = OriginFile
+		origins[key] = origin
+	}
+	return origins
+}
+
+// fillOrigins records origin for every path in raw without a position
+func fillOrigins(value any, path string, origin Origin, origins map[string]Origin) {
+	if path != "" {
+		if _, ok := origins[path]; !ok {
+			origins[path] = origin
+		}
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			fillOrigins(item, joinPath(path, key), origin, origins)
+		}
+	case []any:
+		for i, item := range v {
+			fillOrigins(item, fmt.Sprintf("%s[%d]", path, i), origin, origins)
+		}
+	}
+}
+
+// joinPath appends an object key to a key path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// locateJSON records the position of every key and array element in data
+func locateJSON(path string, data []byte, origins map[string]Origin) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// position returns the origin of the next token after offset
+	position := func(offset int64) Origin {
+		i := int(offset)
+		for i < len(data) && strings.IndexByte(" \t\r\n,:", data[i]) >= 0 {
+			i++
+		}
+		line := bytes.Count(data[:i], []byte("\n")) + 1
+		col := i - bytes.LastIndexByte(data[:i], '\n')
+		return Origin{File: path, Line: line, Column: col}
+	}
+
+	var walk func(keyPath string) error
+	walk = func(keyPath string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				origin := position(dec.InputOffset())
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := keyTok.(string)
+				child := joinPath(keyPath, key)
+				origins[child] = origin
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				child := fmt.Sprintf("%s[%d]", keyPath, i)
+				origins[child] = position(dec.InputOffset())
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+
+	// Syntax errors are reported by the decoder, positions are best effort
+	_ = walk("")
+}
+
+// locateYAML records the position of every key and sequence item in data
+func locateYAML(path string, data []byte, origins map[string]Origin) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return
+	}
+
+	var walk func(node *yaml.Node, keyPath string)
+	walk = func(node *yaml.Node, keyPath string) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				child := joinPath(keyPath, key.Value)
+				origins[child] = Origin{File: path, Line: key.Line, Column: key.Column}
+				walk(value, child)
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				child := fmt.Sprintf("%s[%d]", keyPath, i)
+				origins[child] = Origin{File: path, Line: item.Line, Column: item.Column}
+				walk(item, child)
+			}
+		}
+	}
+	walk(doc.Content[0], "")
+}
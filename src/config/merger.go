@@ -1,36 +1,25 @@
 package config
 
-// Merge merges configuration values from override into base
+import "reflect"
+
+// Merge merges configuration values from override into base. A value is
+// taken from override when it is non-zero or was set explicitly, so that
+// booleans are only turned off when override set them explicitly.
+// Every field with a JSON key takes part, so new fields merge automatically.
 func Merge(base, override *Config) {
-	if override.Input != "" {
-		base.Input = override.Input
-	}
-	if override.Output != "" {
-		base.Output = override.Output
-	}
-	if override.SourceMap != "" {
-		base.SourceMap = override.SourceMap
-	}
-	if override.LogLevel != "" {
-		base.LogLevel = override.LogLevel
-	}
-	if override.Minify {
-		base.Minify = true
+	b := reflect.ValueOf(base).Elem()
+	o := reflect.ValueOf(override).Elem()
+	for i := 0; i < configType.NumField(); i++ {
+		name := jsonName(configType.Field(i))
+		if name == "" {
+			continue
+		}
+		if value := o.Field(i); !value.IsZero() || override.IsSet(name) {
+			b.Field(i).Set(value)
+		}
 	}
-	if override.Report {
-		base.Report = true
-	}
-	if override.Watch {
-		base.Watch = true
-	}
-	// Merge force flags
-	if override.Force {
-		base.Force = true
-	}
-	if override.Yes {
-		base.Yes = true
-	}
-	if override.NoConfirm {
-		base.NoConfirm = true
+
+	for key, origin := range override.Origins {
+		base.SetOrigin(key, origin)
 	}
 }
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PluginConfig enables a plugin for a build. Without a command, the
+// plugin must be compiled into jspackr; with one, it runs as an external
+// process that speaks JSON-RPC over stdio.
+type PluginConfig struct {
+	Name    string         `json:"name" desc:"Name the plugin registered itself under, or a label for an external plugin"`
+	Command []string       `json:"command" desc:"Executable and arguments of an external plugin"`
+	Options map[string]any `json:"options" desc:"Plugin specific options passed to its setup"`
+}
+
+// validatePlugins reports plugins without a name and plugins listed twice
+func validatePlugins(plugins []PluginConfig) []string {
+	var problems []string
+	seen := make(map[string]bool)
+	for i, p := range plugins {
+		switch {
+		case p.Name == "":
+			problems = append(problems, fmt.Sprintf("plugins[%d]: set name", i))
+		case len(p.Command) > 0 && p.Command[0] == "":
+			problems = append(problems, fmt.Sprintf("plugins[%d]: command must start with an executable", i))
+		case seen[p.Name]:
+			problems = append(problems, fmt.Sprintf("plugins[%d]: %q is listed twice", i, p.Name))
+		}
+		seen[p.Name] = true
+	}
+	return problems
+}
+
+// rebasePlugins rewrites relative executable paths of external plugins,
+// found at path, with rebase. Bare names are looked up in PATH and left alone.
+func rebasePlugins(plugins []PluginConfig, path string, rebase func(key, p string) string) {
+	for i := range plugins {
+		command := plugins[i].Command
+		if len(command) == 0 || !strings.ContainsAny(command[0], `/\`) {
+			continue
+		}
+		rebased := append([]string{rebase(fmt.Sprintf("%s[%d].command[0]", path, i), command[0])}, command[1:]...)
+		plugins[i].Command = rebased
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Resolved is the outcome of the config pipeline
+type Resolved struct {
+	Path    string    // Config file in use, or "" for none
+	Root    *Config   // Top-level settings
+	Targets []*Config // Build targets, see Targets
+}
+
+// Resolve runs the config pipeline, each layer overriding the previous:
+// defaults < config file at path (if any) with the given profile <
+// JSPACKR_* environment variables < flags. Environment variables and
+// flags apply to the top level and to every build target. discovered
+// tells whether path was found by walking up from the current directory;
+// see LoadProfile for how that affects relative paths.
+//
+// Problems in the file and validation errors are returned as Errors
+// together with a usable result so that callers can show them all at
+// once. Any other error means nothing could be resolved.
+func Resolve(path, profile string, discovered bool, flags *Config) (*Resolved, error) {
+	if profile != "" && path == "" {
+		return nil, errors.New("--profile requires a config file")
+	}
+
+	var problems Errors
+	root := Default()
+	if path != "" {
+		fileCfg, err := LoadProfile(path, profile, discovered)
+		if err != nil && !errors.As(err, &problems) {
+			return nil, err
+		}
+		root = fileCfg
+	}
+
+	envCfg, err := FromEnv()
+	if err != nil {
+		var errs Errors
+		errors.As(err, &errs)
+		problems = append(problems, errs...)
+	}
+
+	// Expand build targets before applying the environment and flags
+	// so that they override per-build settings as well as top-level ones
+	targets := Targets(root)
+	for _, layer := range []*Config{envCfg, flags} {
+		if layer == nil {
+			continue
+		}
+		for _, target := range targets {
+			Merge(target, layer)
+		}
+		Merge(root, layer)
+	}
+
+	if err := ValidateTargets(targets); err != nil {
+		var errs Errors
+		errors.As(err, &errs)
+		problems = append(problems, errs...)
+	}
+
+	res := &Resolved{Path: path, Root: root, Targets: targets}
+	return res, problems.errorOrNil()
+}
+
+// Entry is a single resolved config value and where it came from
+type Entry struct {
+	Key    string
+	Value  any
+	Source string // "default", a file location, or the variable or flag that set it
+}
+
+// Entries lists the scalar settings of cfg in declaration order
+func (c *Config) Entries() []Entry {
+	v := reflect.ValueOf(c).Elem()
+	var entries []Entry
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		name := jsonName(field)
+		switch field.Type.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Struct:
+			continue
+		}
+		if name == "" {
+			continue
+		}
+
+		source := "default"
+		if c.IsSet(name) {
+			source = c.OriginOf(name).Describe()
+		}
+		entries = append(entries, Entry{Key: name, Value: v.Field(i).Interface(), Source: source})
+	}
+	return entries
+}
+
+// FormatValue formats an entry value for display
+func FormatValue(value any) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(value)
+}
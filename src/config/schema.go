@@ -0,0 +1,132 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+//go:generate sh -c "go run ../main config schema > ../../jspackr.schema.json"
+
+// SchemaURL is the draft the generated schema conforms to
+const SchemaURL = "http://json-schema.org/draft-07/schema#"
+
+// Schema builds a JSON Schema for the config file from the Config struct,
+// its desc and enum tags, and the values of Default
+func Schema() map[string]any {
+	defaults := reflect.ValueOf(Default()).Elem()
+
+	root := objectSchema(configType, defaults)
+	root["$schema"] = SchemaURL
+	root["title"] = "jspackr configuration"
+
+	props := root["properties"].(map[string]any)
+	props["$schema"] = map[string]any{
+		"type":        "string",
+		"description": "JSON Schema used by editors for completion",
+	}
+	props["extends"] = map[string]any{
+		"description": "Base config file(s) to deep-merge this file on top of",
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	props["profiles"] = map[string]any{
+		"type":                 "object",
+		"description":          "Named overrides selected with --profile",
+		"additionalProperties": map[string]any{"$ref": "#/definitions/profile"},
+	}
+
+	// Builds and profiles inherit their defaults from the top level
+	build := objectSchema(configType, reflect.Value{})
+	delete(build["properties"].(map[string]any), "builds")
+	profile := objectSchema(configType, reflect.Value{})
+
+	root["definitions"] = map[string]any{
+		"build":   build,
+		"profile": profile,
+	}
+
+	return root
+}
+
+// SchemaJSON returns the schema as indented JSON
+func SchemaJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Schema(), "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// objectSchema describes struct type t. Defaults are taken from the
+// matching fields of defaults when it is valid.
+func objectSchema(t reflect.Type, defaults reflect.Value) map[string]any {
+	props := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+
+		var def reflect.Value
+		if defaults.IsValid() {
+			def = defaults.Field(i)
+		}
+		props[name] = fieldSchema(field, def)
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+}
+
+// fieldSchema describes a single struct field
+func fieldSchema(field reflect.StructField, def reflect.Value) map[string]any {
+	schema := typeSchema(field.Type)
+	if desc := field.Tag.Get("desc"); desc != "" {
+		schema["description"] = desc
+	}
+	if values := enumValues(field); values != nil {
+		enum := make([]any, len(values))
+		for i, v := range values {
+			enum[i] = v
+		}
+		schema["enum"] = enum
+	}
+	if def.IsValid() && (!def.IsZero() || def.Kind() == reflect.Bool) {
+		schema["default"] = def.Interface()
+	}
+	return schema
+}
+
+// typeSchema maps a Go type to a JSON Schema type
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t == configType {
+			return map[string]any{"$ref": "#/definitions/build"}
+		}
+		return objectSchema(t, reflect.Value{})
+	default:
+		return map[string]any{}
+	}
+}
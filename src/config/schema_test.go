@@ -0,0 +1,23 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestSchemaUpToDate fails when jspackr.schema.json no longer matches the
+// Config struct, i.e. when go generate was not run after changing it
+func TestSchemaUpToDate(t *testing.T) {
+	want, err := SchemaJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../../jspackr.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("jspackr.schema.json is out of date; run go generate ./src/config")
+	}
+}
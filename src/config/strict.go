@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// configType is the reflected type of Config, used for strict key checks
+var configType = reflect.TypeOf(Config{})
+
+// directiveKeys are top-level keys handled by the loader itself, plus
+// the "$schema" key editors use to find the JSON Schema
+var directiveKeys = []string{"$schema", "extends", "profiles"}
+
+// jsonFields maps the lowercased JSON names of t's fields to the field.
+// Lowercased names mirror encoding/json's case-insensitive matching.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := jsonName(f)
+		if name == "" {
+			continue
+		}
+		fields[strings.ToLower(name)] = f
+	}
+	return fields
+}
+
+// jsonName returns the JSON key of a struct field, or "" if it is skipped
+func jsonName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return f.Name
+}
+
+// checkRaw reports unknown keys and values of the wrong type in a parsed
+// config file. Offending values are removed from raw so that the rest of
+// the file can still be decoded and validated.
+func checkRaw(raw map[string]any, origins map[string]Origin) Errors {
+	var errs Errors
+
+	if profiles, ok := raw["profiles"]; ok {
+		entries, isMap := profiles.(map[string]any)
+		if !isMap {
+			errs = append(errs, typeError("profiles", "an object", origins))
+			delete(raw, "profiles")
+		}
+		for name, entry := range entries {
+			path := joinPath("profiles", name)
+			entryErrs, ok := checkValue(entry, configType, path, origins)
+			errs = append(errs, entryErrs...)
+			if !ok {
+				delete(entries, name)
+			}
+		}
+	}
+
+	errs = append(errs, checkObject(raw, configType, "", origins, directiveKeys)...)
+	return errs
+}
+
+// checkObject checks the keys of obj against the fields of struct type t.
+// extra lists additional keys that are allowed but not checked.
+func checkObject(obj map[string]any, t reflect.Type, path string, origins map[string]Origin, extra []string) Errors {
+	fields := jsonFields(t)
+	names := make([]string, 0, len(fields)+len(extra))
+	for _, f := range fields {
+		names = append(names, jsonName(f))
+	}
+	names = append(names, extra...)
+	sort.Strings(names)
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs Errors
+outer:
+	for _, key := range keys {
+		keyPath := joinPath(path, key)
+		for _, name := range extra {
+			if strings.EqualFold(key, name) {
+				continue outer
+			}
+		}
+
+		field, ok := fields[strings.ToLower(key)]
+		if !ok {
+			msg := fmt.Sprintf("unknown key %q", keyPath)
+			if hint := suggest(key, names); hint != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", hint)
+			}
+			errs = append(errs, &Error{Origin: origins[keyPath], Message: msg})
+			delete(obj, key)
+			continue
+		}
+
+		valueErrs, valid := checkValue(obj[key], field.Type, keyPath, origins)
+		errs = append(errs, valueErrs...)
+		if !valid {
+			delete(obj, key)
+		}
+	}
+	return errs
+}
+
+// checkValue checks that value can be decoded into type t. It returns
+// false if the value itself has the wrong shape and must be dropped.
+func checkValue(value any, t reflect.Type, path string, origins map[string]Origin) (Errors, bool) {
+	if value == nil {
+		return nil, true
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return checkValue(value, t.Elem(), path, origins)
+	case reflect.Interface:
+		return nil, true
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			return Errors{typeError(path, "a string", origins)}, false
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			return Errors{typeError(path, "true or false", origins)}, false
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		switch value.(type) {
+		case float64, int, int64, uint64:
+		default:
+			return Errors{typeError(path, "a number", origins)}, false
+		}
+	case reflect.Slice:
+		items, ok := value.([]any)
+		if !ok {
+			return Errors{typeError(path, "an array", origins)}, false
+		}
+		var errs Errors
+		for i, item := range items {
+			itemErrs, valid := checkValue(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), origins)
+			errs = append(errs, itemErrs...)
+			if !valid {
+				items[i] = nil
+			}
+		}
+		return errs, true
+	case reflect.Map:
+		entries, ok := value.(map[string]any)
+		if !ok {
+			return Errors{typeError(path, "an object", origins)}, false
+		}
+		var errs Errors
+		for key, entry := range entries {
+			entryErrs, valid := checkValue(entry, t.Elem(), joinPath(path, key), origins)
+			errs = append(errs, entryErrs...)
+			if !valid {
+				delete(entries, key)
+			}
+		}
+		return errs, true
+	case reflect.Struct:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return Errors{typeError(path, "an object", origins)}, false
+		}
+		return checkObject(obj, t, path, origins, nil), true
+	}
+	return nil, true
+}
+
+// typeError reports that the value at path must be of the given kind
+func typeError(path, want string, origins map[string]Origin) *Error {
+	return &Error{
+		Origin:  origins[path],
+		Message: fmt.Sprintf("%s must be %s", path, want),
+	}
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfig writes a config file into dir and returns its path
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// located is the part of an Error that tests compare
+type located struct {
+	Line, Column int
+	Message      string
+}
+
+// loadErrors loads path and returns its problems in order
+func loadErrors(t *testing.T, path string) []located {
+	t.Helper()
+	cfg, err := Load(path)
+	if err == nil {
+		return nil
+	}
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Load(%s) = %v, want Errors", path, err)
+	}
+	if cfg == nil {
+		t.Fatalf("Load(%s) returned no config along with its problems", path)
+	}
+	got := make([]located, len(errs))
+	for i, e := range errs {
+		if e.Origin.File != path {
+			t.Errorf("error %q is located in %q, want %q", e.Message, e.Origin.File, path)
+		}
+		got[i] = located{e.Origin.Line, e.Origin.Column, e.Message}
+	}
+	return got
+}
+
+func TestLoadUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []located
+	}{
+		{
+			name:    "json",
+			file:    "jspackr.config.json",
+			content: "{\n  \"input\": \"src/index.js\",\n  \"minfy\": true\n}\n",
+			want:    []located{{3, 3, `unknown key "minfy" (did you mean "minify"?)`}},
+		},
+		{
+			name:    "jsonc",
+			file:    "jspackr.config.jsonc",
+			content: "{\n  // bundle\n  \"outptu\": \"dist/app.js\", // typo\n}\n",
+			want:    []located{{3, 3, `unknown key "outptu" (did you mean "output"?)`}},
+		},
+		{
+			name:    "yaml",
+			file:    "jspackr.config.yaml",
+			content: "input: src/index.js\nbudgets:\n  totl:\n    size: 10kb\n",
+			want:    []located{{3, 3, `unknown key "budgets.totl" (did you mean "total"?)`}},
+		},
+		{
+			name:    "nested build",
+			file:    "jspackr.config.json",
+			content: "{\n  \"builds\": [\n    { \"input\": \"a.js\", \"fromat\": \"esm\" }\n  ]\n}\n",
+			want:    []located{{3, 24, `unknown key "builds[0].fromat" (did you mean "format"?)`}},
+		},
+		{
+			name:    "no suggestion",
+			file:    "jspackr.config.json",
+			content: "{\n  \"bundleEverything\": true\n}\n",
+			want:    []located{{2, 3, `unknown key "bundleEverything"`}},
+		},
+		{
+			name:    "directives",
+			file:    "jspackr.config.json",
+			content: "{\n  \"$schema\": \"./jspackr.schema.json\",\n  \"profiles\": {}\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, t.TempDir(), tt.file, tt.content)
+			got := loadErrors(t, path)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got errors %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("error %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadAggregatesErrors(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "jspackr.config.json", `{
+  "watch": "yes",
+  "input": "src/index.js",
+  "allowCycles": ["src/a.js", 3],
+  "minfy": true,
+  "budgets": { "total": { "size": 10 } }
+}
+`)
+	got := loadErrors(t, path)
+	want := []located{
+		{2, 3, "watch must be true or false"},
+		{4, 31, "allowCycles[1] must be a string"},
+		{5, 3, `unknown key "minfy" (did you mean "minify"?)`},
+		{6, 27, "budgets.total.size must be a string"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got errors %+v, want %+v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("error %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// The keys that are valid still load
+	cfg, _ := Load(path)
+	if cfg.Input != "src/index.js" || cfg.Watch {
+		t.Errorf("config = input %q, watch %v, want the valid keys only", cfg.Input, cfg.Watch)
+	}
+}
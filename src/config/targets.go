@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// Targets expands cfg into the list of builds to run. Without a builds
+// array the config itself is the only target; otherwise every entry
+// is merged on top of a copy of the top-level settings.
+func Targets(cfg *Config) []*Config {
+	if len(cfg.Builds) == 0 {
+		return []*Config{clone(cfg)}
+	}
+
+	targets := make([]*Config, 0, len(cfg.Builds))
+	for i := range cfg.Builds {
+		target := clone(cfg)
+		target.Builds = nil
+		override := cfg.Builds[i]
+		override.Builds = nil
+		Merge(target, &override)
+		if target.Name == "" || target.Name == cfg.Name {
+			target.Name = fmt.Sprintf("build %d", i+1)
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// clone returns a copy of cfg that does not share its Origins
+func clone(cfg *Config) *Config {
+	c := *cfg
+	c.Origins = nil
+	for key, origin := range cfg.Origins {
+		c.SetOrigin(key, origin)
+	}
+	return &c
+}
+
+// ValidateTargets validates every target and makes sure no two targets
+// write the same output file or manifest. All problems are collected into Errors;
+// a problem inherited by several targets is reported once.
+func ValidateTargets(targets []*Config) error {
+	var errs Errors
+	seen := make(map[string]bool)
+	outputs := make(map[string]string)
+	manifests := make(map[string]string)
+
+	for _, target := range targets {
+		var targetErrs Errors
+		if err := Validate(target); err != nil && !errors.As(err, &targetErrs) {
+			targetErrs = Errors{&Error{Message: err.Error()}}
+		}
+
+		out := filepath.Clean(target.Output)
+		if other, ok := outputs[out]; ok && target.Output != "" {
+			targetErrs = append(targetErrs, &Error{
+				Origin:  target.OriginOf("output"),
+				Message: fmt.Sprintf("%s and %s both write to %s", other, target.Name, target.Output),
+			})
+		}
+		outputs[out] = target.Name
+
+		manifest := filepath.Clean(target.Manifest)
+		if other, ok := manifests[manifest]; ok && target.Manifest != "" {
+			targetErrs = append(targetErrs, &Error{
+				Origin:  target.OriginOf("manifest"),
+				Message: fmt.Sprintf("%s and %s both write the manifest %s; give each build its own", other, target.Name, target.Manifest),
+			})
+		}
+		manifests[manifest] = target.Name
+
+		for _, e := range targetErrs {
+			key := e.Error()
+			if seen[key] && e.Origin.File != "" {
+				continue
+			}
+			seen[key] = true
+			if len(targets) > 1 && e.Origin.File == "" {
+				e.Message = target.Name + ": " + e.Message
+			}
+			errs = append(errs, e)
+		}
+	}
+
+	return errs.errorOrNil()
+}
@@ -2,22 +2,73 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 )
 
-// Validate validates the configuration
+// Validate validates the configuration and reports every problem found.
+// The returned error is of type Errors, located at the offending key
+// when the value came from a config file.
 func Validate(cfg *Config) error {
+	var errs Errors
+	add := func(key, msg string) {
+		errs = append(errs, &Error{Origin: cfg.OriginOf(key), Message: msg})
+	}
+
 	if cfg.Input == "" {
-		return errors.New("entry file is required")
+		add("input", "entry file is required")
+	}
+
+	// Keys with an enum tag must hold one of the listed values
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		values := enumValues(field)
+		if values == nil {
+			continue
+		}
+		value := v.Field(i).String()
+		if !slices.Contains(values, value) {
+			name := jsonName(field)
+			add(name, fmt.Sprintf("invalid %s %q: use %s", name, value, orList(values)))
+		}
+	}
+
+	for _, problem := range validateBudgets(cfg.Budgets) {
+		add("budgets", problem)
 	}
+	for _, problem := range validateBoundaries(cfg.Boundaries) {
+		add("boundaries", problem)
+	}
+	for _, problem := range validatePlugins(cfg.Plugins) {
+		add("plugins", problem)
+	}
+	for _, problem := range validateVirtual(cfg.Virtual) {
+		add("virtual", problem)
+	}
+
+	return errs.errorOrNil()
+}
 
-	switch cfg.SourceMap {
-	case "none", "l", "in":
+// enumValues returns the allowed values of a field, or nil if any value is allowed
+func enumValues(field reflect.StructField) []string {
+	tag := field.Tag.Get("enum")
+	if tag == "" {
 		return nil
-	default:
-		return errors.New("invalid sourcemap mode: use none, l, or in")
 	}
+	return strings.Split(tag, ",")
+}
+
+// orList formats values as "a, b, or c"
+func orList(values []string) string {
+	if len(values) < 2 {
+		return strings.Join(values, "")
+	}
+	return strings.Join(values[:len(values)-1], ", ") + ", or " + values[len(values)-1]
 }
 
 // ValidateInputPath checks if the input path exists
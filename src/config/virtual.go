@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// VirtualModule is a module that exists only at build time. Exactly one
+// of Contents, Command and Generate provides its source.
+type VirtualModule struct {
+	Contents string `json:"contents" desc:"Source of the module"`
+	Command  string `json:"command" desc:"Shell command whose standard output is the source of the module"`
+	Generate string `json:"generate" desc:"Built-in generator: git, timestamp, version or build-info" enum:"git,timestamp,version,build-info"`
+	Loader   string `json:"loader" desc:"How the source is parsed; js by default" enum:"js,jsx,ts,tsx,css,json,text"`
+	Dir      string `json:"dir" desc:"Directory the command runs in and imports are resolved from"`
+}
+
+// validateVirtual reports modules without exactly one source and
+// unknown generators and loaders
+func validateVirtual(modules map[string]VirtualModule) []string {
+	specifiers := make([]string, 0, len(modules))
+	for specifier := range modules {
+		specifiers = append(specifiers, specifier)
+	}
+	sort.Strings(specifiers)
+
+	t := reflect.TypeOf(VirtualModule{})
+	generateField, _ := t.FieldByName("Generate")
+	loaderField, _ := t.FieldByName("Loader")
+	generators, loaders := enumValues(generateField), enumValues(loaderField)
+
+	var problems []string
+	for _, specifier := range specifiers {
+		m := modules[specifier]
+		sources := 0
+		for _, s := range []string{m.Contents, m.Command, m.Generate} {
+			if s != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			problems = append(problems, fmt.Sprintf("virtual %q: set exactly one of contents, command and generate", specifier))
+		}
+		if m.Generate != "" && !slices.Contains(generators, m.Generate) {
+			problems = append(problems, fmt.Sprintf("virtual %q: invalid generate %q: use %s", specifier, m.Generate, orList(generators)))
+		}
+		if m.Loader != "" && !slices.Contains(loaders, m.Loader) {
+			problems = append(problems, fmt.Sprintf("virtual %q: invalid loader %q: use %s", specifier, m.Loader, orList(loaders)))
+		}
+	}
+	return problems
+}
+
+// rebaseVirtual rewrites the directories of modules, found at path, with
+// rebase. Modules without one run in the directory of the file that
+// declares them.
+func rebaseVirtual(modules map[string]VirtualModule, path string, rebase func(key, p string) string) {
+	for specifier, m := range modules {
+		if m.Dir == "" {
+			m.Dir = "."
+		}
+		m.Dir = rebase(path+"."+specifier+".dir", m.Dir)
+		modules[specifier] = m
+	}
+}
@@ -0,0 +1,142 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/core/builder"
+)
+
+// Check modes
+const (
+	ModeOff   = "off"
+	ModeWarn  = "warn"
+	ModeError = "error"
+)
+
+// Checks returns the post-build checks enabled in cfg
+func Checks(cfg *config.Config) []builder.Check {
+	var checks []builder.Check
+	if cfg.Duplicates != "" && cfg.Duplicates != ModeOff {
+		checks = append(checks, duplicatesCheck(cfg.Duplicates))
+	}
+	if cfg.Cycles != "" && cfg.Cycles != ModeOff {
+		checks = append(checks, cyclesCheck(cfg.Cycles, cfg.AllowCycles))
+	}
+	return checks
+}
+
+// duplicatesCheck reports duplicate packages, failing the build in
+// error mode
+func duplicatesCheck(mode string) builder.Check {
+	return func(result builder.BuildResult) error {
+		meta, err := builder.ParseMetafile(result.Metafile)
+		if err != nil {
+			return err
+		}
+		dups := FindDuplicates(meta)
+		if len(dups) == 0 {
+			return nil
+		}
+
+		PrintDuplicates(dups, result.Name, mode == ModeError)
+		if mode != ModeError {
+			return nil
+		}
+		if len(dups) == 1 {
+			return fmt.Errorf("package %s is bundled %d times", dups[0].Name, len(dups[0].Copies))
+		}
+		return fmt.Errorf("%d packages are bundled more than once", len(dups))
+	}
+}
+
+// cyclesCheck reports import cycles among first-party modules, failing
+// the build in error mode
+func cyclesCheck(mode string, allow []string) builder.Check {
+	return func(result builder.BuildResult) error {
+		meta, err := builder.ParseMetafile(result.Metafile)
+		if err != nil {
+			return err
+		}
+		cycles, truncated := FindCycles(meta, allow)
+		if len(cycles) == 0 {
+			return nil
+		}
+
+		PrintCycles(cycles, truncated, result.Name, mode == ModeError)
+		if mode != ModeError {
+			return nil
+		}
+		if len(cycles) == 1 {
+			return fmt.Errorf("import cycle: %s", strings.Join(cycles[0], " → "))
+		}
+		return fmt.Errorf("%d import cycles", len(cycles))
+	}
+}
+
+// checkStyle returns the header style and icon for a check's findings
+func checkStyle(failing bool) (*color.Color, string) {
+	if failing {
+		return cli.DefaultStyles.Error, cli.IconsDefault.Error
+	}
+	return cli.DefaultStyles.Warn, cli.IconsDefault.Warn
+}
+
+// printHeader prints the title of a check's findings, labelled with the
+// build name when there are several builds
+func printHeader(title, name string, failing bool) {
+	style, icon := checkStyle(failing)
+	fmt.Println()
+	if name != "" {
+		style.Printf("%s %s [%s]:\n", icon, title, name)
+	} else {
+		style.Printf("%s %s:\n", icon, title)
+	}
+}
+
+// PrintDuplicates prints every duplicate package with the size of each
+// copy and the import chains that pulled it in
+func PrintDuplicates(dups []Duplicate, name string, failing bool) {
+	wasted := 0
+	for _, d := range dups {
+		wasted += d.Wasted
+	}
+	printHeader(fmt.Sprintf("Duplicate packages (%d, %s wasted)", len(dups), builder.FormatBytes(int64(wasted))), name, failing)
+
+	for _, d := range dups {
+		cli.DefaultStyles.Highlight.Printf("  %s", d.Name)
+		cli.DefaultStyles.Dim.Printf(" %d copies, ", len(d.Copies))
+		cli.DefaultStyles.Stats.Printf("%s wasted\n", builder.FormatBytes(int64(d.Wasted)))
+		for _, c := range d.Copies {
+			version := c.Version
+			if version == "" {
+				version = "?"
+			}
+			cli.DefaultStyles.Value.Printf("    %-10s ", version)
+			cli.DefaultStyles.Path.Printf("%-50s ", c.Root)
+			cli.DefaultStyles.Stats.Printf("%10s\n", builder.FormatBytes(int64(c.Bytes)))
+			for _, ch := range c.Chains {
+				cli.DefaultStyles.Dim.Printf("      %s\n", strings.Join(ch, " → "))
+			}
+		}
+	}
+	fmt.Println()
+}
+
+// PrintCycles prints every import cycle as a chain of modules
+func PrintCycles(cycles []Cycle, truncated bool, name string, failing bool) {
+	title := fmt.Sprintf("Import cycles (%d)", len(cycles))
+	if truncated {
+		title = fmt.Sprintf("Import cycles (first %d)", len(cycles))
+	}
+	printHeader(title, name, failing)
+
+	for _, c := range cycles {
+		cli.DefaultStyles.Dim.Printf("  %s\n", strings.Join(c, " → "))
+	}
+	cli.DefaultStyles.Dim.Println("  Add modules to allowCycles to ignore their cycles")
+	fmt.Println()
+}
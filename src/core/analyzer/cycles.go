@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/kalokaradia/jspackr/src/core/builder"
+	"github.com/kalokaradia/jspackr/src/utils"
+)
+
+// maxCycles and maxSteps limit the search, since the number of cycles
+// and paths can grow exponentially with the size of a tangled module group
+const (
+	maxCycles = 100
+	maxSteps  = 1_000_000
+)
+
+// Cycle is a chain of imports that leads back to its first module. The
+// first module is repeated at the end.
+type Cycle []string
+
+// cycleKinds are the import kinds that run the imported module before
+// the importer. Dynamic imports cannot cause initialization order bugs.
+var cycleKinds = map[string]bool{
+	"import-statement": true,
+	"require-call":     true,
+}
+
+// FindCycles returns the import cycles among first-party modules, that
+// is modules outside node_modules. Cycles through a module matching one
+// of the allow patterns are left out. The second result reports whether
+// the search was cut short.
+func FindCycles(meta *builder.MetaFile, allow []string) ([]Cycle, bool) {
+	g := NewGraph(meta)
+
+	allowed := func(path string) bool {
+		for _, pattern := range allow {
+			if utils.MatchGlob(pattern, path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Adjacency between the first-party modules taking part
+	edges := make(map[string][]string)
+	var nodes []string
+	for _, path := range g.Modules() {
+		if _, _, pkg := packageOf(path); pkg || allowed(path) {
+			continue
+		}
+		nodes = append(nodes, path)
+		seen := make(map[string]bool)
+		for _, imp := range g.Imports[path] {
+			if !cycleKinds[imp.Kind] || seen[imp.Path] {
+				continue
+			}
+			if _, _, pkg := packageOf(imp.Path); pkg || allowed(imp.Path) {
+				continue
+			}
+			seen[imp.Path] = true
+			edges[path] = append(edges[path], imp.Path)
+		}
+	}
+
+	// Every cycle is found once, from its smallest module, by only
+	// visiting modules that sort after the start
+	var cycles []Cycle
+	truncated := false
+	index := make(map[string]int, len(nodes))
+	for i, n := range nodes {
+		index[n] = i
+	}
+
+	onPath := make(map[string]bool)
+	var stack []string
+	steps := 0
+	var visit func(start, node string)
+	visit = func(start, node string) {
+		if steps++; steps > maxSteps {
+			truncated = true
+		}
+		if truncated {
+			return
+		}
+		stack = append(stack, node)
+		onPath[node] = true
+		for _, next := range edges[node] {
+			switch {
+			case next == start:
+				if len(cycles) == maxCycles {
+					truncated = true
+					break
+				}
+				cycle := append(Cycle{}, stack...)
+				cycles = append(cycles, append(cycle, start))
+			case !onPath[next] && index[next] > index[start]:
+				visit(start, next)
+			}
+		}
+		onPath[node] = false
+		stack = stack[:len(stack)-1]
+	}
+	for _, start := range nodes {
+		visit(start, start)
+	}
+
+	sort.SliceStable(cycles, func(i, j int) bool {
+		return len(cycles[i]) < len(cycles[j])
+	})
+	return cycles, truncated
+}
@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/kalokaradia/jspackr/src/core/builder"
+)
+
+// Delta is the change in size of an output or module between two builds
+type Delta struct {
+	Path   string
+	Before int64
+	After  int64
+}
+
+// Change returns the difference in bytes
+func (d Delta) Change() int64 {
+	return d.After - d.Before
+}
+
+// AbsChange returns the difference in bytes regardless of direction
+func (d Delta) AbsChange() int64 {
+	if change := d.Change(); change < 0 {
+		return -change
+	}
+	return d.Change()
+}
+
+// Added reports whether the path is new in the later build
+func (d Delta) Added() bool {
+	return d.Before == 0 && d.After > 0
+}
+
+// Removed reports whether the path is gone from the later build
+func (d Delta) Removed() bool {
+	return d.Before > 0 && d.After == 0
+}
+
+// Comparison holds the per-output and per-module changes between builds
+type Comparison struct {
+	Outputs []Delta // Every output, in path order
+	Modules []Delta // Changed modules, largest change first
+	Before  int64   // Total size of all outputs before
+	After   int64   // Total size of all outputs after
+}
+
+// Compare compares two builds. Module sizes are the bytes each module
+// adds to the output, summed over all outputs.
+func Compare(before, after *builder.Stats) *Comparison {
+	c := &Comparison{}
+
+	outputs := make(map[string]*Delta)
+	modules := make(map[string]*Delta)
+	add := func(stats *builder.Stats, set func(d *Delta, n int64)) {
+		for _, out := range stats.Outputs {
+			d, ok := outputs[out.Path]
+			if !ok {
+				d = &Delta{Path: out.Path}
+				outputs[out.Path] = d
+			}
+			set(d, out.Bytes)
+			for path, bytes := range out.Modules {
+				m, ok := modules[path]
+				if !ok {
+					m = &Delta{Path: path}
+					modules[path] = m
+				}
+				set(m, int64(bytes))
+			}
+		}
+	}
+	add(before, func(d *Delta, n int64) { d.Before += n })
+	add(after, func(d *Delta, n int64) { d.After += n })
+
+	for _, d := range outputs {
+		c.Outputs = append(c.Outputs, *d)
+		c.Before += d.Before
+		c.After += d.After
+	}
+	sort.Slice(c.Outputs, func(i, j int) bool {
+		return c.Outputs[i].Path < c.Outputs[j].Path
+	})
+
+	for _, d := range modules {
+		if d.Change() != 0 {
+			c.Modules = append(c.Modules, *d)
+		}
+	}
+	sort.Slice(c.Modules, func(i, j int) bool {
+		a, b := c.Modules[i].AbsChange(), c.Modules[j].AbsChange()
+		if a != b {
+			return a > b
+		}
+		return c.Modules[i].Path < c.Modules[j].Path
+	})
+
+	return c
+}
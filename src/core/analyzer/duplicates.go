@@ -0,0 +1,162 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kalokaradia/jspackr/src/core/builder"
+)
+
+// maxChains limits the import chains listed for each copy of a package
+const maxChains = 3
+
+// Duplicate is a package bundled from more than one location
+type Duplicate struct {
+	Name   string
+	Copies []PackageCopy // Largest first
+	Wasted int           // Bytes of every copy but the largest
+}
+
+// PackageCopy is one installed location of a package and the import
+// chains, from an entry point, that pulled it into the bundle
+type PackageCopy struct {
+	Root    string // Package directory, such as node_modules/a/node_modules/b
+	Version string // From its package.json, if it can be read
+	Bytes   int    // Bytes in the output
+	Chains  [][]string
+}
+
+// packageOf returns the package name and directory of a module inside
+// node_modules, using the innermost node_modules directory
+func packageOf(path string) (name, root string, ok bool) {
+	parts := strings.Split(path, "/")
+	at := -1
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] == "node_modules" {
+			at = i
+			break
+		}
+	}
+	if at < 0 || at+1 >= len(parts)-1 {
+		return "", "", false
+	}
+
+	name = parts[at+1]
+	end := at + 2
+	if strings.HasPrefix(name, "@") {
+		if at+2 >= len(parts)-1 {
+			return "", "", false
+		}
+		name += "/" + parts[at+2]
+		end++
+	}
+	return name, strings.Join(parts[:end], "/"), true
+}
+
+// packageVersion reads the version from the package.json in root
+func packageVersion(root string) string {
+	data, err := os.ReadFile(filepath.Join(filepath.FromSlash(root), "package.json"))
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return ""
+	}
+	return pkg.Version
+}
+
+// FindDuplicates reports packages whose modules were bundled from more
+// than one package directory, largest waste first
+func FindDuplicates(meta *builder.MetaFile) []Duplicate {
+	// Bytes in the output of every package directory, by package name
+	sizes := make(map[string]map[string]int)
+	for _, out := range meta.Outputs {
+		for path, in := range out.Inputs {
+			name, root, ok := packageOf(path)
+			if !ok {
+				continue
+			}
+			if sizes[name] == nil {
+				sizes[name] = make(map[string]int)
+			}
+			sizes[name][root] += in.BytesInOutput
+		}
+	}
+
+	graph := NewGraph(meta)
+	parents := graph.shortestParents()
+
+	var dups []Duplicate
+	for name, roots := range sizes {
+		if len(roots) < 2 {
+			continue
+		}
+		dup := Duplicate{Name: name}
+		for root, bytes := range roots {
+			dup.Copies = append(dup.Copies, PackageCopy{
+				Root:    root,
+				Version: packageVersion(root),
+				Bytes:   bytes,
+				Chains:  importChains(graph, parents, root),
+			})
+		}
+		sort.Slice(dup.Copies, func(i, j int) bool {
+			if dup.Copies[i].Bytes != dup.Copies[j].Bytes {
+				return dup.Copies[i].Bytes > dup.Copies[j].Bytes
+			}
+			return dup.Copies[i].Root < dup.Copies[j].Root
+		})
+		for _, c := range dup.Copies[1:] {
+			dup.Wasted += c.Bytes
+		}
+		dups = append(dups, dup)
+	}
+
+	sort.Slice(dups, func(i, j int) bool {
+		if dups[i].Wasted != dups[j].Wasted {
+			return dups[i].Wasted > dups[j].Wasted
+		}
+		return dups[i].Name < dups[j].Name
+	})
+	return dups
+}
+
+// importChains returns the shortest chain from an entry point through
+// each module outside root that imports a module inside it
+func importChains(g *Graph, parents map[string]string, root string) [][]string {
+	inside := func(path string) bool {
+		return strings.HasPrefix(path, root+"/")
+	}
+
+	var chains [][]string
+	seen := make(map[string]bool)
+	for _, path := range g.Modules() {
+		if inside(path) {
+			continue
+		}
+		if _, reachable := parents[path]; !reachable {
+			continue
+		}
+		for _, imp := range g.Imports[path] {
+			if !inside(imp.Path) || seen[path] {
+				continue
+			}
+			seen[path] = true
+			chains = append(chains, append(chain(parents, path), imp.Path))
+		}
+	}
+
+	sort.SliceStable(chains, func(i, j int) bool {
+		return len(chains[i]) < len(chains[j])
+	})
+	if len(chains) > maxChains {
+		chains = chains[:maxChains]
+	}
+	return chains
+}
@@ -0,0 +1,223 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Package modes for graph exports
+const (
+	PackagesShow     = "show"     // Every module inside node_modules
+	PackagesCollapse = "collapse" // One node per package
+	PackagesHide     = "hide"     // First-party modules only
+)
+
+// ExportOptions filters an exported graph
+type ExportOptions struct {
+	Packages string // One of the Packages* modes
+	Depth    int    // Maximum import depth from the entry points, 0 for all
+}
+
+// ExportNode is a module or, with collapsed packages, a whole package
+type ExportNode struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"` // KindModule or KindPackage
+	Entry   bool   `json:"entry,omitempty"`
+	Depth   int    `json:"depth"`
+	Modules int    `json:"modules,omitempty"` // Modules in a collapsed package
+}
+
+// ExportEdge is an import between two nodes
+type ExportEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"` // esbuild import kind
+}
+
+// Export is a filtered import graph ready to be written out
+type Export struct {
+	Nodes []ExportNode `json:"nodes"`
+	Edges []ExportEdge `json:"edges"`
+}
+
+// Export applies opts to the graph
+func (g *Graph) Export(opts ExportOptions) *Export {
+	// Node a module is shown as, or "" if it is hidden
+	nodeOf := func(path string) string {
+		name, _, ok := packageOf(path)
+		if !ok {
+			return path
+		}
+		switch opts.Packages {
+		case PackagesHide:
+			return ""
+		case PackagesCollapse:
+			return name
+		default:
+			return path
+		}
+	}
+
+	// Depth of every module from the nearest entry point
+	depths := make(map[string]int)
+	queue := make([]string, 0, len(g.Entries))
+	for _, entry := range g.Entries {
+		depths[entry] = 0
+		queue = append(queue, entry)
+	}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		for _, imp := range g.Imports[path] {
+			if _, ok := depths[imp.Path]; !ok {
+				depths[imp.Path] = depths[path] + 1
+				queue = append(queue, imp.Path)
+			}
+		}
+	}
+	visible := func(path string) bool {
+		depth, ok := depths[path]
+		return ok && (opts.Depth <= 0 || depth <= opts.Depth) && nodeOf(path) != ""
+	}
+
+	entries := make(map[string]bool)
+	for _, entry := range g.Entries {
+		entries[entry] = true
+	}
+
+	nodes := make(map[string]*ExportNode)
+	edges := make(map[[2]string]string)
+	for _, path := range g.Modules() {
+		if !visible(path) {
+			continue
+		}
+		id := nodeOf(path)
+		node, ok := nodes[id]
+		if !ok {
+			node = &ExportNode{ID: id, Kind: KindModule, Depth: depths[path]}
+			if id != path {
+				node.Kind = KindPackage
+			}
+			nodes[id] = node
+		}
+		node.Depth = min(node.Depth, depths[path])
+		node.Entry = node.Entry || entries[path]
+		if node.Kind == KindPackage {
+			node.Modules++
+		}
+
+		for _, imp := range g.Imports[path] {
+			if !visible(imp.Path) {
+				continue
+			}
+			to := nodeOf(imp.Path)
+			if to == id {
+				continue
+			}
+			// A static import wins over dynamic ones between the same nodes
+			key := [2]string{id, to}
+			if kind, ok := edges[key]; !ok || kind == "dynamic-import" {
+				edges[key] = imp.Kind
+			}
+		}
+	}
+
+	out := &Export{Nodes: []ExportNode{}, Edges: []ExportEdge{}}
+	for _, node := range nodes {
+		out.Nodes = append(out.Nodes, *node)
+	}
+	sort.Slice(out.Nodes, func(i, j int) bool {
+		return out.Nodes[i].ID < out.Nodes[j].ID
+	})
+	for key, kind := range edges {
+		out.Edges = append(out.Edges, ExportEdge{From: key[0], To: key[1], Kind: kind})
+	}
+	sort.Slice(out.Edges, func(i, j int) bool {
+		if out.Edges[i].From != out.Edges[j].From {
+			return out.Edges[i].From < out.Edges[j].From
+		}
+		return out.Edges[i].To < out.Edges[j].To
+	})
+	return out
+}
+
+// WriteJSON writes the graph as indented JSON
+func (e *Export) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Entry points are
+// drawn bold, packages as boxes and dynamic imports dashed.
+func (e *Export) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph imports {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=ellipse, fontname=\"Helvetica\", fontsize=10];\n")
+	for _, n := range e.Nodes {
+		var attrs []string
+		if n.Kind == KindPackage {
+			attrs = append(attrs, "shape=box", "style=filled", "fillcolor=\"#f0b37e\"")
+		}
+		if n.Entry {
+			attrs = append(attrs, "penwidth=2")
+		}
+		fmt.Fprintf(&b, "  %s", dotQuote(n.ID))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	for _, edge := range e.Edges {
+		fmt.Fprintf(&b, "  %s -> %s", dotQuote(edge.From), dotQuote(edge.To))
+		if edge.Kind == "dynamic-import" {
+			b.WriteString(" [style=dashed]")
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote quotes s as a DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart. Node IDs are
+// generated since module paths are not valid Mermaid identifiers.
+func (e *Export) WriteMermaid(w io.Writer) error {
+	ids := make(map[string]string, len(e.Nodes))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range e.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[n.ID] = id
+		label := strings.ReplaceAll(n.ID, `"`, "#quot;")
+		switch {
+		case n.Kind == KindPackage:
+			fmt.Fprintf(&b, "  %s[[\"%s\"]]\n", id, label)
+		case n.Entry:
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", id, label)
+		default:
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, label)
+		}
+	}
+	for _, edge := range e.Edges {
+		arrow := "-->"
+		if edge.Kind == "dynamic-import" {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", ids[edge.From], arrow, ids[edge.To])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
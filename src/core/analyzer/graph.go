@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/kalokaradia/jspackr/src/core/builder"
+)
+
+// Graph is the import graph of a build: which bundled module imports
+// which, starting from the entry points
+type Graph struct {
+	Entries []string
+	Imports map[string][]builder.MetaImport
+}
+
+// NewGraph builds the import graph of meta. External imports, which are
+// not part of the bundle, are left out.
+func NewGraph(meta *builder.MetaFile) *Graph {
+	g := &Graph{Imports: make(map[string][]builder.MetaImport, len(meta.Inputs))}
+
+	seen := make(map[string]bool)
+	for _, out := range meta.Outputs {
+		if out.EntryPoint != "" && !seen[out.EntryPoint] {
+			seen[out.EntryPoint] = true
+			g.Entries = append(g.Entries, out.EntryPoint)
+		}
+	}
+	sort.Strings(g.Entries)
+
+	for path, in := range meta.Inputs {
+		var imports []builder.MetaImport
+		for _, imp := range in.Imports {
+			if !imp.External {
+				imports = append(imports, imp)
+			}
+		}
+		sort.SliceStable(imports, func(i, j int) bool {
+			return imports[i].Path < imports[j].Path
+		})
+		g.Imports[path] = imports
+	}
+	return g
+}
+
+// Modules returns every module in the graph in path order
+func (g *Graph) Modules() []string {
+	modules := make([]string, 0, len(g.Imports))
+	for path := range g.Imports {
+		modules = append(modules, path)
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// shortestParents walks the graph breadth first from the entry points
+// and returns the module each reachable module was first imported from.
+// Entry points map to "".
+func (g *Graph) shortestParents() map[string]string {
+	parents := make(map[string]string)
+	queue := make([]string, 0, len(g.Entries))
+	for _, entry := range g.Entries {
+		parents[entry] = ""
+		queue = append(queue, entry)
+	}
+
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		for _, imp := range g.Imports[path] {
+			if _, ok := parents[imp.Path]; ok {
+				continue
+			}
+			parents[imp.Path] = path
+			queue = append(queue, imp.Path)
+		}
+	}
+	return parents
+}
+
+// chain follows parents back from path to its entry point
+func chain(parents map[string]string, path string) []string {
+	var modules []string
+	for p := path; p != ""; p = parents[p] {
+		modules = append([]string{p}, modules...)
+	}
+	return modules
+}
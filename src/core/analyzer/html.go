@@ -0,0 +1,38 @@
+package analyzer
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html"
+	"os"
+	"strings"
+)
+
+//go:embed treemap.html
+var treemapTemplate string
+
+// RenderHTML returns a self-contained HTML page with a zoomable treemap
+// of tree. The data is embedded, so the page works offline.
+func RenderHTML(tree *Node, title string) ([]byte, error) {
+	// json.Marshal escapes <, > and &, so the data cannot close the
+	// surrounding script element
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	page := strings.NewReplacer(
+		"{{TITLE}}", html.EscapeString(title),
+		"{{DATA}}", string(data),
+	).Replace(treemapTemplate)
+	return []byte(page), nil
+}
+
+// WriteHTML renders the treemap of tree to path
+func WriteHTML(path string, tree *Node, title string) error {
+	page, err := RenderHTML(tree, title)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, page, 0644)
+}
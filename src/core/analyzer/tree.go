@@ -0,0 +1,140 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kalokaradia/jspackr/src/core/builder"
+)
+
+// Node kinds
+const (
+	KindRoot    = "root"
+	KindOutput  = "output"
+	KindPackage = "package"
+	KindDir     = "dir"
+	KindModule  = "module"
+)
+
+// Node is a box in the treemap: an output file, a package, a directory
+// or a single module
+type Node struct {
+	Name     string  `json:"name"`
+	Kind     string  `json:"kind"`
+	Path     string  `json:"path,omitempty"`
+	Bytes    int     `json:"bytes"`            // Bytes in the output
+	Source   int     `json:"source,omitempty"` // Original source bytes, for modules
+	Children []*Node `json:"children,omitempty"`
+}
+
+// BuildTree groups the output bytes contributed by every input by output
+// file, then package (for node_modules) and directory. Inputs that were
+// tree-shaken away entirely are left out since they take no space.
+func BuildTree(meta *builder.MetaFile) *Node {
+	root := &Node{Name: "bundle", Kind: KindRoot}
+
+	for outPath, out := range meta.Outputs {
+		if len(out.Inputs) == 0 {
+			continue
+		}
+		outNode := &Node{Name: outPath, Kind: KindOutput, Path: outPath}
+		root.Children = append(root.Children, outNode)
+
+		for inPath, in := range out.Inputs {
+			if in.BytesInOutput == 0 {
+				continue
+			}
+			insert(outNode, inPath, in.BytesInOutput, meta.Inputs[inPath].Bytes)
+		}
+	}
+
+	finish(root)
+	return root
+}
+
+// segment is one level of an input path in the tree
+type segment struct {
+	name string
+	kind string
+}
+
+// splitPath turns an input path into tree levels. "node_modules/<pkg>"
+// (or "node_modules/@scope/pkg") becomes a single package level.
+func splitPath(path string) []segment {
+	parts := strings.Split(path, "/")
+	var segs []segment
+	for i := 0; i < len(parts)-1; i++ {
+		part := parts[i]
+		if part == "node_modules" && i+1 < len(parts)-1 {
+			pkg := parts[i+1]
+			i++
+			if strings.HasPrefix(pkg, "@") && i+1 < len(parts)-1 {
+				pkg += "/" + parts[i+1]
+				i++
+			}
+			segs = append(segs, segment{name: pkg, kind: KindPackage})
+			continue
+		}
+		if part == "" || part == "." {
+			continue
+		}
+		segs = append(segs, segment{name: part, kind: KindDir})
+	}
+	return append(segs, segment{name: parts[len(parts)-1], kind: KindModule})
+}
+
+// insert adds a module below parent, creating intermediate nodes
+func insert(parent *Node, path string, bytes, source int) {
+	segs := splitPath(path)
+	node := parent
+	for _, seg := range segs[:len(segs)-1] {
+		node = child(node, seg)
+	}
+	last := segs[len(segs)-1]
+	node.Children = append(node.Children, &Node{
+		Name:   last.name,
+		Kind:   last.kind,
+		Path:   path,
+		Bytes:  bytes,
+		Source: source,
+	})
+}
+
+// child returns the child of node for seg, creating it if needed
+func child(node *Node, seg segment) *Node {
+	for _, c := range node.Children {
+		if c.Name == seg.name && c.Kind == seg.kind {
+			return c
+		}
+	}
+	c := &Node{Name: seg.name, Kind: seg.kind}
+	node.Children = append(node.Children, c)
+	return c
+}
+
+// finish sums sizes bottom-up, collapses directory chains with a single
+// child directory (a/b/c) and sorts children by size
+func finish(node *Node) int {
+	if len(node.Children) == 0 {
+		return node.Bytes
+	}
+
+	node.Bytes = 0
+	for _, c := range node.Children {
+		node.Bytes += finish(c)
+	}
+
+	for node.Kind == KindDir && len(node.Children) == 1 && node.Children[0].Kind == KindDir {
+		only := node.Children[0]
+		node.Name += "/" + only.Name
+		node.Children = only.Children
+	}
+
+	sort.Slice(node.Children, func(i, j int) bool {
+		if node.Children[i].Bytes != node.Children[j].Bytes {
+			return node.Children[i].Bytes > node.Children[j].Bytes
+		}
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	return node.Bytes
+}
@@ -0,0 +1,242 @@
+package analyzer
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/kalokaradia/jspackr/src/core/builder"
+	"github.com/kalokaradia/jspackr/src/utils"
+)
+
+// sourceExts are the file types checked for unused files
+var sourceExts = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".ts": true, ".tsx": true, ".mts": true, ".cts": true,
+	".css": true,
+}
+
+// DefaultUnusedIgnore lists files that are never bundled on purpose
+var DefaultUnusedIgnore = []string{
+	"**/*.test.*",
+	"**/*.spec.*",
+	"**/__tests__/**",
+	"**/*.d.ts",
+}
+
+// SourceRoot returns the directory holding the entry points of meta,
+// used as the default root for unused file checks
+func SourceRoot(meta *builder.MetaFile) string {
+	root := ""
+	for _, entry := range NewGraph(meta).Entries {
+		dir := filepath.ToSlash(filepath.Dir(entry))
+		for root != "" && dir != root && !strings.HasPrefix(dir, root+"/") {
+			root = filepath.ToSlash(filepath.Dir(root))
+		}
+		if root == "" {
+			root = dir
+		}
+	}
+	if root == "" {
+		return "."
+	}
+	return root
+}
+
+// FindUnusedFiles lists the source files under root that are not part of
+// the bundle. node_modules, dot directories, the build outputs and files
+// matching an ignore pattern are skipped.
+func FindUnusedFiles(meta *builder.MetaFile, root string, ignore []string) ([]string, error) {
+	used := make(map[string]bool, len(meta.Inputs))
+	for path := range meta.Inputs {
+		used[filepath.ToSlash(filepath.Clean(path))] = true
+	}
+	outputs := make(map[string]bool, len(meta.Outputs))
+	for path := range meta.Outputs {
+		outputs[filepath.ToSlash(filepath.Clean(path))] = true
+	}
+
+	var unused []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		slash := filepath.ToSlash(filepath.Clean(path))
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "node_modules" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !sourceExts[filepath.Ext(path)] || used[slash] || outputs[slash] {
+			return nil
+		}
+		for _, pattern := range ignore {
+			if utils.MatchGlob(pattern, slash) {
+				return nil
+			}
+		}
+		unused = append(unused, slash)
+		return nil
+	})
+	return unused, err
+}
+
+// UnusedExport is a module with exported names no bundled module imports
+type UnusedExport struct {
+	Path  string
+	Names []string
+}
+
+// Patterns for the import and re-export statements of a module after
+// esbuild has stripped comments and types from it
+var (
+	importFrom   = regexp.MustCompile(`(?s)\bimport\s+([^"'();]+?)\s+from\s*["']([^"']+)["']`)
+	exportFrom   = regexp.MustCompile(`(?s)\bexport\s*\{([^}]*)\}\s*from\s*["']([^"']+)["']`)
+	exportAll    = regexp.MustCompile(`\bexport\s*\*\s*(?:as\s+[\w$]+\s*)?from\s*["']([^"']+)["']`)
+	namedImports = regexp.MustCompile(`\{([^}]*)\}`)
+)
+
+// FindUnusedExports lists the exports of first-party modules that no
+// bundled module imports. Exports of entry points are their public API
+// and are never reported; namespace imports, export * and dynamic
+// imports count as using every export.
+func FindUnusedExports(meta *builder.MetaFile) ([]UnusedExport, error) {
+	g := NewGraph(meta)
+
+	var modules []string
+	for _, path := range g.Modules() {
+		if _, _, pkg := packageOf(path); pkg || !sourceExts[filepath.Ext(path)] || filepath.Ext(path) == ".css" {
+			continue
+		}
+		modules = append(modules, path)
+	}
+	if len(modules) == 0 {
+		return nil, nil
+	}
+
+	// Transform every module on its own to get its export names and
+	// import statements without comments or types
+	result := api.Build(api.BuildOptions{
+		EntryPoints: modules,
+		Outdir:      "jspackr-unused",
+		Format:      api.FormatESModule,
+		Metafile:    true,
+		Write:       false,
+		LogLevel:    api.LogLevelSilent,
+	})
+	if len(result.Errors) > 0 {
+		return nil, errors.New(result.Errors[0].Text)
+	}
+	transformed, err := builder.ParseMetafile(result.Metafile)
+	if err != nil {
+		return nil, err
+	}
+	code := make(map[string]string)
+	for _, file := range result.OutputFiles {
+		code[filepath.ToSlash(file.Path)] = string(file.Contents)
+	}
+
+	exports := make(map[string][]string)
+	sources := make(map[string]string)
+	for outPath, out := range transformed.Outputs {
+		if out.EntryPoint == "" {
+			continue
+		}
+		exports[out.EntryPoint] = out.Exports
+		if abs, err := filepath.Abs(outPath); err == nil {
+			sources[out.EntryPoint] = code[filepath.ToSlash(abs)]
+		}
+	}
+
+	// Names imported from each module; "*" stands for all of them
+	used := make(map[string]map[string]bool)
+	use := func(path, name string) {
+		if used[path] == nil {
+			used[path] = make(map[string]bool)
+		}
+		used[path][name] = true
+	}
+	for _, path := range modules {
+		resolve := make(map[string]string)
+		for _, imp := range g.Imports[path] {
+			resolve[imp.Original] = imp.Path
+			if imp.Kind != "import-statement" {
+				use(imp.Path, "*")
+			}
+		}
+
+		src := sources[path]
+		for _, m := range importFrom.FindAllStringSubmatch(src, -1) {
+			if target, ok := resolve[m[2]]; ok {
+				for _, name := range importedNames(m[1]) {
+					use(target, name)
+				}
+			}
+		}
+		for _, m := range exportFrom.FindAllStringSubmatch(src, -1) {
+			if target, ok := resolve[m[2]]; ok {
+				for _, spec := range strings.Split(m[1], ",") {
+					if name, _, _ := strings.Cut(strings.TrimSpace(spec), " "); name != "" {
+						use(target, name)
+					}
+				}
+			}
+		}
+		for _, m := range exportAll.FindAllStringSubmatch(src, -1) {
+			if target, ok := resolve[m[1]]; ok {
+				use(target, "*")
+			}
+		}
+	}
+
+	entries := make(map[string]bool)
+	for _, entry := range g.Entries {
+		entries[entry] = true
+	}
+
+	var unused []UnusedExport
+	for _, path := range modules {
+		if entries[path] || used[path]["*"] {
+			continue
+		}
+		var names []string
+		for _, name := range exports[path] {
+			if !used[path][name] {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			unused = append(unused, UnusedExport{Path: path, Names: names})
+		}
+	}
+	return unused, nil
+}
+
+// importedNames parses an import clause such as `a, { b as c, d }` or
+// `* as ns` into the exported names it uses
+func importedNames(clause string) []string {
+	if strings.Contains(clause, "*") {
+		return []string{"*"}
+	}
+
+	var names []string
+	if m := namedImports.FindStringSubmatch(clause); m != nil {
+		for _, spec := range strings.Split(m[1], ",") {
+			if name, _, _ := strings.Cut(strings.TrimSpace(spec), " "); name != "" {
+				names = append(names, strings.Trim(name, `"'`))
+			}
+		}
+		clause = namedImports.ReplaceAllString(clause, "")
+	}
+	if def := strings.TrimSpace(strings.Trim(strings.TrimSpace(clause), ",")); def != "" {
+		names = append(names, "default")
+	}
+	return names
+}
@@ -0,0 +1,166 @@
+package analyzer
+
+import (
+	"strings"
+)
+
+// maxPaths limits how many import paths are listed for a query
+const maxPaths = 50
+
+// Step is one module on an import path and how the previous module
+// imported it. The entry point has no kind.
+type Step struct {
+	Path string
+	Kind string
+}
+
+// Targets finds the modules a query refers to: a module path, a package
+// name, or the end of a module path. For packages every module imported
+// from outside the package is a target.
+func (g *Graph) Targets(query string) []string {
+	query = strings.TrimPrefix(query, "./")
+	if _, ok := g.Imports[query]; ok {
+		return []string{query}
+	}
+
+	inPackage := func(path string) bool {
+		name, _, ok := packageOf(path)
+		return ok && name == query
+	}
+	var targets []string
+	for _, path := range g.Modules() {
+		if !inPackage(path) {
+			continue
+		}
+		if g.importedFromOutside(path, inPackage) {
+			targets = append(targets, path)
+		}
+	}
+	if len(targets) > 0 {
+		return targets
+	}
+
+	for _, path := range g.Modules() {
+		if strings.HasSuffix(path, "/"+query) {
+			targets = append(targets, path)
+		}
+	}
+	return targets
+}
+
+// importedFromOutside reports whether a module outside the set described
+// by inside imports path, or whether path is an entry point
+func (g *Graph) importedFromOutside(path string, inside func(string) bool) bool {
+	for _, entry := range g.Entries {
+		if entry == path {
+			return true
+		}
+	}
+	for from, imports := range g.Imports {
+		if inside(from) {
+			continue
+		}
+		for _, imp := range imports {
+			if imp.Path == path {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ImportPaths lists every import path without repeated modules from an
+// entry point to one of the targets, shortest first. The search stops at
+// the first target reached on each path. The second result reports
+// whether the list was cut short.
+//
+// Paths are grown backwards from the targets, breadth first, so they are
+// found in order of length and a cut list keeps the shortest ones.
+func (g *Graph) ImportPaths(targets []string) ([][]Step, bool) {
+	isTarget := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		isTarget[t] = true
+	}
+	isEntry := make(map[string]bool, len(g.Entries))
+	for _, entry := range g.Entries {
+		isEntry[entry] = true
+	}
+
+	// importers maps each module to the modules importing it, in a stable order
+	type importer struct{ path, kind string }
+	importers := make(map[string][]importer)
+	for _, from := range g.Modules() {
+		for _, imp := range g.Imports[from] {
+			importers[imp.Path] = append(importers[imp.Path], importer{from, imp.Kind})
+		}
+	}
+
+	// trail is a path being grown backwards: path imports next.path
+	// with kind, down to a target
+	type trail struct {
+		path string
+		kind string
+		next *trail
+	}
+	contains := func(t *trail, path string) bool {
+		for ; t != nil; t = t.next {
+			if t.path == path {
+				return true
+			}
+		}
+		return false
+	}
+
+	var paths [][]Step
+	queue := make([]*trail, 0, len(targets))
+	for _, t := range targets {
+		queue = append(queue, &trail{path: t})
+	}
+	steps := 0
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		if isEntry[t.path] {
+			if len(paths) == maxPaths {
+				return paths, true
+			}
+			path := []Step{{Path: t.path}}
+			for n := t; n.next != nil; n = n.next {
+				path = append(path, Step{Path: n.next.path, Kind: n.kind})
+			}
+			paths = append(paths, path)
+		}
+		for _, imp := range importers[t.path] {
+			if isTarget[imp.path] || contains(t, imp.path) {
+				continue
+			}
+			if steps++; steps > maxSteps {
+				return paths, true
+			}
+			queue = append(queue, &trail{path: imp.path, kind: imp.kind, next: t})
+		}
+	}
+	return paths, false
+}
+
+// ImportKind describes an esbuild import kind in a few words
+func ImportKind(kind string) string {
+	switch kind {
+	case "import-statement":
+		return "static"
+	case "dynamic-import":
+		return "dynamic"
+	case "require-call":
+		return "require"
+	case "require-resolve":
+		return "require.resolve"
+	case "import-rule":
+		return "css @import"
+	case "composes-from":
+		return "css composes"
+	case "url-token":
+		return "css url()"
+	default:
+		return kind
+	}
+}
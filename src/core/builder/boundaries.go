@@ -0,0 +1,138 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/utils"
+)
+
+// boundaryCheck marks the resolves issued by the boundaries plugin itself
+type boundaryCheck struct{}
+
+// boundariesPlugin fails the build when an import breaks one of rules.
+// esbuild reports the error at the offending import statement.
+func boundariesPlugin(rules []config.Boundary) api.Plugin {
+	cwd, _ := os.Getwd()
+	rel := func(path string) string {
+		if r, err := filepath.Rel(cwd, path); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return filepath.ToSlash(path)
+	}
+
+	// Patterns rebased onto a parent directory ("../src/ui") are made
+	// relative to the working directory like the paths they are matched with
+	norm := func(pattern string) string {
+		if !strings.Contains(pattern, "/") {
+			return pattern
+		}
+		if abs, err := filepath.Abs(pattern); err == nil {
+			return rel(abs)
+		}
+		return pattern
+	}
+	normalized := make([]config.Boundary, len(rules))
+	for i, rule := range rules {
+		rule.From = norm(rule.From)
+		disallow := make([]string, len(rule.Disallow))
+		for j, pattern := range rule.Disallow {
+			disallow[j] = norm(pattern)
+		}
+		rule.Disallow = disallow
+		normalized[i] = rule
+	}
+	rules = normalized
+
+	return api.Plugin{
+		Name: "jspackr:boundaries",
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: ".*"}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				if args.Kind == api.ResolveEntryPoint || args.Importer == "" || args.Namespace != "file" {
+					return api.OnResolveResult{}, nil
+				}
+				if _, ok := args.PluginData.(boundaryCheck); ok {
+					return api.OnResolveResult{}, nil
+				}
+
+				importer := rel(args.Importer)
+				pkg := packageName(args.Path)
+
+				// Resolved lazily, since most imports match no rule
+				var resolved string
+				resolve := func() string {
+					if resolved == "" {
+						result := build.Resolve(args.Path, api.ResolveOptions{
+							Importer:   args.Importer,
+							Namespace:  args.Namespace,
+							ResolveDir: args.ResolveDir,
+							Kind:       args.Kind,
+							PluginData: boundaryCheck{},
+						})
+						if len(result.Errors) > 0 || result.External {
+							resolved = "-"
+						} else {
+							resolved = rel(result.Path)
+						}
+					}
+					return resolved
+				}
+
+				var errs []api.Message
+				for _, rule := range rules {
+					if rule.From != "" && !utils.MatchGlob(rule.From, importer) {
+						continue
+					}
+					if broken := breaks(rule, pkg, resolve); broken != "" {
+						text := fmt.Sprintf("%s may not import %s", importer, broken)
+						if rule.Message != "" {
+							text += ": " + rule.Message
+						}
+						errs = append(errs, api.Message{Text: text})
+					}
+				}
+				return api.OnResolveResult{Errors: errs}, nil
+			})
+		},
+	}
+}
+
+// breaks returns what an import disallowed by rule refers to, or "" if
+// the rule allows it
+func breaks(rule config.Boundary, pkg string, resolve func() string) string {
+	for _, name := range rule.DisallowPackages {
+		if pkg == name {
+			return "package " + name
+		}
+	}
+	if len(rule.Disallow) == 0 {
+		return ""
+	}
+	path := resolve()
+	if path == "-" {
+		return ""
+	}
+	for _, pattern := range rule.Disallow {
+		if utils.MatchGlob(pattern, path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// packageName returns the package a bare import specifier refers to,
+// such as "lodash" for "lodash/fp", or "" for relative and absolute paths
+func packageName(specifier string) string {
+	if specifier == "" || strings.HasPrefix(specifier, ".") || filepath.IsAbs(specifier) || strings.HasPrefix(specifier, "/") {
+		return ""
+	}
+	parts := strings.SplitN(specifier, "/", 3)
+	if strings.HasPrefix(parts[0], "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
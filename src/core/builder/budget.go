@@ -0,0 +1,299 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+)
+
+// BaselineDir holds the metafile of the last build of each target that
+// stayed within its budgets, used to show which modules grew. It is only
+// written when the budgets of a target set baseline.
+const BaselineDir = ".jspackr/budgets"
+
+// budgetViolation is a single exceeded limit
+type budgetViolation struct {
+	Label   string   // What was measured, such as "output dist/app.js"
+	Gzip    bool     // Whether Size is the gzip size
+	Size    int64    // Measured size
+	Limit   int64    // Configured limit
+	Outputs []string // Outputs counted towards Size
+}
+
+// CheckBudgets compares the outputs of a build against its budgets.
+// Exceeded budgets are printed with the modules that grew since the last
+// build within budget, when a baseline is kept. It returns an error
+// unless budgets only warn. An output or entry budget that matches
+// nothing the build wrote is always an error, as it is most likely a
+// typo that would turn the budget off.
+func CheckBudgets(opts Options, result BuildResult) error {
+	if opts.Budgets.Empty() || opts.DryRun {
+		return nil
+	}
+
+	meta, err := ParseMetafile(result.Metafile)
+	if err != nil {
+		return fmt.Errorf("budgets: %w", err)
+	}
+
+	violations, unmatched := checkBudgets(opts.Budgets, meta, result.Outputs)
+	if len(unmatched) > 0 {
+		return unmatchedBudgets(unmatched, meta)
+	}
+	baseline := baselinePath(opts)
+	if len(violations) == 0 {
+		if opts.Budgets.Baseline {
+			// Remember this build as the reference for future growth
+			if err := os.MkdirAll(filepath.Dir(baseline), 0755); err == nil {
+				_ = os.WriteFile(baseline, []byte(result.Metafile), 0644)
+			}
+		}
+		return nil
+	}
+
+	var before *MetaFile
+	if opts.Budgets.Baseline {
+		before, _ = LoadMetafile(baseline)
+	}
+	printViolations(opts, violations, before, meta)
+
+	if opts.Budgets.Warn {
+		return nil
+	}
+	if len(violations) == 1 {
+		return fmt.Errorf("size budget exceeded")
+	}
+	return fmt.Errorf("%d size budgets exceeded", len(violations))
+}
+
+// unmatchedBudgets reports output and entry budgets that match nothing
+func unmatchedBudgets(labels []string, meta *MetaFile) error {
+	outputs := make([]string, 0, len(meta.Outputs))
+	for path := range meta.Outputs {
+		if filepath.Ext(path) != ".map" {
+			outputs = append(outputs, path)
+		}
+	}
+	sort.Strings(outputs)
+	return fmt.Errorf("budgets: %s matches nothing this build wrote (outputs: %s)", strings.Join(labels, ", "), strings.Join(outputs, ", "))
+}
+
+// checkBudgets measures the outputs in meta against budgets. It also
+// returns the labels of output and entry budgets that match no output.
+func checkBudgets(budgets config.Budgets, meta *MetaFile, files []OutputFile) ([]budgetViolation, []string) {
+	sizes := make(map[string]int64)
+	gzipped := make(map[string]int64)
+	for _, file := range files {
+		gzipped[filepath.ToSlash(file.Path)] = file.Gzip
+	}
+	outputs := make([]string, 0, len(meta.Outputs))
+	for path, out := range meta.Outputs {
+		outputs = append(outputs, path)
+		sizes[path] = int64(out.Bytes)
+	}
+	sort.Strings(outputs)
+
+	// Gzip sizes of source maps are only computed when a budget asks for them
+	gzipOf := func(path string) int64 {
+		if size, ok := gzipped[path]; ok {
+			return size
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0
+		}
+		gzipped[path] = gzipSize(data)
+		return gzipped[path]
+	}
+
+	var violations []budgetViolation
+	var unmatched []string
+	check := func(label string, budget config.Budget, paths []string) {
+		if budget.Size != "" {
+			limit, _ := config.ParseSize(budget.Size)
+			var size int64
+			for _, p := range paths {
+				size += sizes[p]
+			}
+			if size > limit {
+				violations = append(violations, budgetViolation{Label: label, Size: size, Limit: limit, Outputs: paths})
+			}
+		}
+		if budget.Gzip != "" {
+			limit, _ := config.ParseSize(budget.Gzip)
+			var size int64
+			for _, p := range paths {
+				size += gzipOf(p)
+			}
+			if size > limit {
+				violations = append(violations, budgetViolation{Label: label, Gzip: true, Size: size, Limit: limit, Outputs: paths})
+			}
+		}
+	}
+
+	// Source maps never reach users, so they only count when asked for by type
+	var assets []string
+	for _, path := range outputs {
+		if filepath.Ext(path) != ".map" {
+			assets = append(assets, path)
+		}
+	}
+
+	if !budgets.Total.Empty() {
+		check("total", budgets.Total, assets)
+	}
+	for _, key := range sortedKeys(budgets.Outputs) {
+		var paths []string
+		for _, path := range outputs {
+			if filepath.Clean(path) == key {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			unmatched = append(unmatched, "output "+key)
+		}
+		check("output "+key, budgets.Outputs[key], paths)
+	}
+	for _, key := range sortedKeys(budgets.Entries) {
+		var paths []string
+		for _, path := range assets {
+			if filepath.Clean(meta.Outputs[path].EntryPoint) == key {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			unmatched = append(unmatched, "entry "+key)
+		}
+		check("entry "+key, budgets.Entries[key], paths)
+	}
+	for _, key := range sortedKeys(budgets.Types) {
+		ext := "." + strings.TrimPrefix(strings.ToLower(key), ".")
+		var paths []string
+		for _, path := range outputs {
+			if strings.ToLower(filepath.Ext(path)) == ext {
+				paths = append(paths, path)
+			}
+		}
+		check("type "+key, budgets.Types[key], paths)
+	}
+
+	return violations, unmatched
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]config.Budget) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// baselinePath returns where the last build within budget of a target is kept
+func baselinePath(opts Options) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(filepath.Clean(opts.Output))
+	return filepath.Join(BaselineDir, name+".json")
+}
+
+// moduleDelta is the change in bytes a module adds to the output
+type moduleDelta struct {
+	Path  string
+	Delta int
+	New   bool
+}
+
+// moduleGrowth lists the modules of outputs whose size in the output grew
+// between before and after, largest growth first
+func moduleGrowth(before, after *MetaFile, outputs []string) []moduleDelta {
+	sum := func(meta *MetaFile) map[string]int {
+		sizes := make(map[string]int)
+		for _, out := range outputs {
+			for path, in := range meta.Outputs[out].Inputs {
+				sizes[path] += in.BytesInOutput
+			}
+		}
+		return sizes
+	}
+
+	old, current := sum(before), sum(after)
+	var deltas []moduleDelta
+	for path, size := range current {
+		prev, existed := old[path]
+		if size > prev {
+			deltas = append(deltas, moduleDelta{Path: path, Delta: size - prev, New: !existed})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].Delta != deltas[j].Delta {
+			return deltas[i].Delta > deltas[j].Delta
+		}
+		return deltas[i].Path < deltas[j].Path
+	})
+	return deltas
+}
+
+// printViolations prints every exceeded budget with the modules that
+// grew, or the largest modules when there is no earlier build to compare
+func printViolations(opts Options, violations []budgetViolation, before, after *MetaFile) {
+	style, icon := cli.DefaultStyles.Error, cli.IconsDefault.Error
+	if opts.Budgets.Warn {
+		style, icon = cli.DefaultStyles.Warn, cli.IconsDefault.Warn
+	}
+
+	fmt.Println()
+	if opts.Name != "" {
+		style.Printf("%s Size budgets exceeded [%s]:\n", icon, opts.Name)
+	} else {
+		style.Printf("%s Size budgets exceeded:\n", icon)
+	}
+
+	for _, v := range violations {
+		kind := "size"
+		if v.Gzip {
+			kind = "gzip"
+		}
+		cli.DefaultStyles.Key.Printf("  %s (%s):", v.Label, kind)
+		cli.DefaultStyles.Stats.Printf(" %s", FormatBytes(v.Size))
+		cli.DefaultStyles.Dim.Printf(" / %s budget, ", FormatBytes(v.Limit))
+		style.Printf("+%s over\n", FormatBytes(v.Size-v.Limit))
+
+		if before != nil {
+			growth := moduleGrowth(before, after, v.Outputs)
+			if len(growth) == 0 {
+				cli.DefaultStyles.Dim.Println("    No module grew since the last build within budget")
+				continue
+			}
+			cli.DefaultStyles.Dim.Println("    Grew since the last build within budget:")
+			for i, d := range growth {
+				if i == 5 {
+					cli.DefaultStyles.Dim.Printf("      ... and %d more\n", len(growth)-i)
+					break
+				}
+				note := ""
+				if d.New {
+					note = " (new)"
+				}
+				cli.DefaultStyles.Dim.Printf("      %-48s ", d.Path)
+				cli.DefaultStyles.Value.Printf("+%s%s\n", FormatBytes(int64(d.Delta)), note)
+			}
+			continue
+		}
+
+		largest := moduleGrowth(&MetaFile{}, after, v.Outputs)
+		if len(largest) == 0 {
+			continue
+		}
+		cli.DefaultStyles.Dim.Println("    Largest modules (no earlier build within budget to compare):")
+		for i := 0; i < len(largest) && i < 5; i++ {
+			cli.DefaultStyles.Dim.Printf("      %-48s ", largest[i].Path)
+			cli.DefaultStyles.Value.Printf("%s\n", FormatBytes(int64(largest[i].Delta)))
+		}
+	}
+	fmt.Println()
+}
@@ -7,81 +7,245 @@ import (
 	"time"
 
 	"github.com/evanw/esbuild/pkg/api"
+	"github.com/kalokaradia/jspackr/src/config"
 )
 
 // Options defines build options
 type Options struct {
-	Input     string
-	Output    string
-	Minify    bool
-	Report    bool
-	SourceMap string
+	Name       string
+	Input      string
+	Output     string
+	Minify     bool
+	Report     bool
+	SourceMap  string
+	Format     string
+	Platform   string
+	Preset     string
+	Compress   bool   // Write .gz and .br files next to each output
+	Metafile   bool   // Collect the metafile even without a report
+	DryRun     bool   // Build in memory without writing output files
+	History    string // Append build stats to this file
+	Budgets    config.Budgets
+	Boundaries []config.Boundary
+	Plugins    []config.PluginConfig
+	Virtual    map[string]config.VirtualModule
+	PublicDir  string     // Copied into the output directory
+	Manifest   string     // Write the list of files the build wrote here
+	Checks     []Check    // Run after a successful build
+	OnGlob     func(Glob) // Called with every import.meta.glob pattern expanded
+}
+
+// Check inspects a finished build, printing what it finds. It returns an
+// error to fail the build.
+type Check func(result BuildResult) error
+
+// FromConfig returns the build options for a resolved config target
+func FromConfig(cfg *config.Config) Options {
+	return Options{
+		Name:       cfg.Name,
+		Input:      cfg.Input,
+		Output:     cfg.Output,
+		Minify:     cfg.Minify,
+		Report:     cfg.Report,
+		SourceMap:  cfg.SourceMap,
+		Format:     cfg.Format,
+		Platform:   cfg.Platform,
+		Preset:     cfg.Preset,
+		Compress:   cfg.Compress,
+		History:    cfg.History,
+		Budgets:    cfg.Budgets,
+		Boundaries: cfg.Boundaries,
+		Plugins:    cfg.Plugins,
+		Virtual:    cfg.Virtual,
+		PublicDir:  cfg.PublicDir,
+		Manifest:   cfg.Manifest,
+	}
 }
 
 // Run execute the build process with given options
 func Run(opts Options) error {
+	result, err := Build(opts)
+	if err != nil {
+		return err
+	}
+
+	PrintReport(result)
+
+	return afterBuild(opts, result)
+}
+
+// afterBuild records the history of a build and runs its budget and
+// other checks, returning every failure
+func afterBuild(opts Options, result BuildResult) error {
+	if err := RecordHistory(opts, result); err != nil {
+		return err
+	}
+
+	errs := []error{CheckBudgets(opts, result)}
+	for _, check := range opts.Checks {
+		errs = append(errs, check(result))
+	}
+	return errors.Join(errs...)
+}
+
+// Build executes the build process without printing a report
+func Build(opts Options) (BuildResult, error) {
 	if opts.Input == "" {
-		return errors.New("input file is required")
+		return BuildResult{}, errors.New("input file is required")
 	}
 
 	// Validate input path exists
 	info, err := os.Stat(opts.Input)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return errors.New("input path does not exist: " + opts.Input)
+			return BuildResult{}, errors.New("input path does not exist: " + opts.Input)
 		}
-		return err
+		return BuildResult{}, err
 	}
 	if info.IsDir() {
 		entries, err := os.ReadDir(opts.Input)
 		if err != nil {
-			return err
+			return BuildResult{}, err
 		}
 		if len(entries) == 0 {
-			return errors.New("input directory is empty: " + opts.Input)
+			return BuildResult{}, errors.New("input directory is empty: " + opts.Input)
 		}
 	}
 
 	// make sure output directory exists
-	if dir := filepath.Dir(opts.Output); dir != "." {
+	if dir := filepath.Dir(opts.Output); dir != "." && !opts.DryRun {
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			return err
+			return BuildResult{}, err
 		}
 	}
 
 	start := time.Now()
 
-	// execute build
-	result := api.Build(api.BuildOptions{
+	buildOpts := api.BuildOptions{
 		EntryPoints:       []string{opts.Input},
 		Bundle:            true,
 		MinifyWhitespace:  opts.Minify,
 		MinifyIdentifiers: opts.Minify,
 		MinifySyntax:      opts.Minify,
 		Outfile:           opts.Output,
-		Write:             true,
-		Platform:          api.PlatformBrowser,
-		Metafile:          opts.Report,
+		Write:             !opts.DryRun,
+		Format:            MapFormat(opts.Format),
+		Platform:          MapPlatform(opts.Platform),
+		Metafile:          opts.Report || opts.Metafile || opts.History != "" || opts.Manifest != "" || !opts.Budgets.Empty() || len(opts.Checks) > 0,
 		Sourcemap:         MapSourceMap(opts.SourceMap),
-	})
+	}
+	ApplyPreset(opts.Preset, &buildOpts)
+	// Boundaries come first so that they see every import, including
+	// those user plugins resolve
+	if len(opts.Boundaries) > 0 {
+		buildOpts.Plugins = append(buildOpts.Plugins, boundariesPlugin(opts.Boundaries))
+	}
+	if len(opts.Virtual) > 0 {
+		buildOpts.Plugins = append(buildOpts.Plugins, virtualPlugin(opts.Virtual))
+	}
+	plugins, err := userPlugins(opts)
+	if err != nil {
+		return BuildResult{}, err
+	}
+	buildOpts.Plugins = append(buildOpts.Plugins, plugins...)
+	// Last, so that user plugins can load files themselves
+	buildOpts.Plugins = append(buildOpts.Plugins, globPlugin(opts.OnGlob))
+
+	// execute build
+	result := api.Build(buildOpts)
 
 	if len(result.Errors) > 0 {
-		return errors.New(result.Errors[0].Text)
+		return BuildResult{}, buildError(result.Errors)
+	}
+
+	outputs, err := outputFiles(opts, result)
+	if err != nil {
+		return BuildResult{}, err
+	}
+	public, err := publicFiles(opts, result)
+	if err != nil {
+		return BuildResult{}, err
+	}
+	if err := writeManifest(opts, result, public); err != nil {
+		return BuildResult{}, err
 	}
 
 	elapsed := time.Since(start)
 
 	// Build report
 	buildResult := BuildResult{
-		OutputPath: opts.Output,
-		InputSize:  GetInputSize(result.Metafile),
-		OutputSize: GetOutputSize(opts.Output),
+		Name:        opts.Name,
+		OutputPath:  opts.Output,
+		InputSize:   GetInputSize(result.Metafile),
+		OutputSize:  outputSize(opts, result),
 		ModuleCount: GetModuleCount(result.Metafile),
 		Elapsed:     elapsed,
 		Metafile:    result.Metafile,
+		Report:      opts.Report,
+		Outputs:     outputs,
+		Public:      public,
 	}
 
-	PrintReport(buildResult)
+	return buildResult, nil
+}
+
+// outputSize returns the size of the written bundle, or of the in-memory
+// bundle for dry runs
+func outputSize(opts Options, result api.BuildResult) int64 {
+	if !opts.DryRun {
+		return GetOutputSize(opts.Output)
+	}
+	var size int64
+	for _, file := range result.OutputFiles {
+		if filepath.Ext(file.Path) != ".map" {
+			size += int64(len(file.Contents))
+		}
+	}
+	return size
+}
 
-	return nil
+// outputFiles measures every output except source maps, writing
+// precompressed copies when requested. Compressed sizes are only measured
+// when the report, history or budgets use them. Dry runs skip compression.
+func outputFiles(opts Options, result api.BuildResult) ([]OutputFile, error) {
+	if opts.DryRun {
+		return nil, nil
+	}
+	measure := opts.Report || opts.History != "" || !opts.Budgets.Empty()
+	cwd, _ := os.Getwd()
+	var files []OutputFile
+	for _, file := range result.OutputFiles {
+		if filepath.Ext(file.Path) == ".map" {
+			continue
+		}
+		path := file.Path
+		if rel, err := filepath.Rel(cwd, file.Path); err == nil {
+			path = rel
+		}
+		out, err := compressOutput(path, file.Contents, measure, opts.Compress)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, out)
+	}
+	return files, nil
+}
+
+// publicFiles copies the public directory next to the outputs, checking
+// for conflicts with every file the build wrote. Dry runs copy nothing.
+func publicFiles(opts Options, result api.BuildResult) ([]PublicFile, error) {
+	if opts.DryRun {
+		return nil, nil
+	}
+	var written []string
+	for _, file := range result.OutputFiles {
+		written = append(written, file.Path)
+		if opts.Compress && filepath.Ext(file.Path) != ".map" {
+			written = append(written, file.Path+".gz", file.Path+".br")
+		}
+	}
+	if opts.Manifest != "" {
+		written = append(written, opts.Manifest)
+	}
+	return CopyPublic(opts, written)
 }
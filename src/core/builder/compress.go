@@ -0,0 +1,73 @@
+package builder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Compressed sizes that are only reported are measured at the default
+// levels, which are close to what servers use when compressing on the fly
+// and far cheaper than the best levels used for precompressed files.
+const (
+	measureGzipLevel   = gzip.DefaultCompression
+	measureBrotliLevel = brotli.DefaultCompression
+)
+
+// gzipBytes compresses data with gzip at the given level
+func gzipBytes(data []byte, level int) []byte {
+	var buf bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&buf, level)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+// gzipSize returns the gzip compressed size of data
+func gzipSize(data []byte) int64 {
+	return int64(len(gzipBytes(data, measureGzipLevel)))
+}
+
+// brotliBytes compresses data with brotli at the given level
+func brotliBytes(data []byte, level int) []byte {
+	var buf bytes.Buffer
+	w := brotli.NewWriterLevel(&buf, level)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+// OutputFile describes a file written by a build with its sizes over
+// the wire. Gzip and Brotli are zero when they were not measured.
+type OutputFile struct {
+	Path   string
+	Bytes  int64
+	Gzip   int64
+	Brotli int64
+}
+
+// compressOutput measures the compressed sizes of an output when measure
+// is set and, when write is set, saves them next to it as .gz and .br
+// files at the best level so static servers can send them as is
+func compressOutput(path string, data []byte, measure, write bool) (OutputFile, error) {
+	out := OutputFile{Path: path, Bytes: int64(len(data))}
+	if !write {
+		if measure {
+			out.Gzip = int64(len(gzipBytes(data, measureGzipLevel)))
+			out.Brotli = int64(len(brotliBytes(data, measureBrotliLevel)))
+		}
+		return out, nil
+	}
+
+	gz, br := gzipBytes(data, gzip.BestCompression), brotliBytes(data, brotli.BestCompression)
+	out.Gzip, out.Brotli = int64(len(gz)), int64(len(br))
+	if err := os.WriteFile(path+".gz", gz, 0644); err != nil {
+		return out, err
+	}
+	if err := os.WriteFile(path+".br", br, 0644); err != nil {
+		return out, err
+	}
+	return out, nil
+}
@@ -0,0 +1,47 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// buildError combines esbuild error messages into one error, each with
+// the file, line and column it points at and the offending source line
+func buildError(msgs []api.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	if len(msgs) > 1 {
+		fmt.Fprintf(&b, "%d errors:\n", len(msgs))
+	}
+	for i, msg := range msgs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(formatMessage(msg))
+	}
+	return errors.New(b.String())
+}
+
+// formatMessage formats a single esbuild message
+func formatMessage(msg api.Message) string {
+	text := msg.Text
+	if msg.PluginName != "" && !strings.HasPrefix(msg.PluginName, "jspackr:") {
+		text = fmt.Sprintf("[plugin %s] %s", msg.PluginName, text)
+	}
+
+	loc := msg.Location
+	if loc == nil {
+		return text
+	}
+	s := fmt.Sprintf("%s:%d:%d: %s", loc.File, loc.Line, loc.Column+1, text)
+	if loc.LineText != "" {
+		s += "\n    " + strings.TrimRight(loc.LineText, "\r\n")
+	}
+	return s
+}
@@ -0,0 +1,623 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/kalokaradia/jspackr/src/utils"
+)
+
+// globCall is how import.meta.glob calls start in source code
+const globCall = "import.meta.glob("
+
+// Glob is an import.meta.glob pattern expanded during a build
+type Glob struct {
+	Dir     string // Absolute directory relative patterns start from
+	Pattern string // Pattern as written, relative to Dir or, with a leading slash, to the working directory
+}
+
+// Root returns the directory below which files can match the pattern
+func (g Glob) Root() string {
+	pattern := g.abs()
+	if i := strings.IndexAny(pattern, "*?{"); i >= 0 {
+		pattern = pattern[:i]
+		return filepath.Clean(filepath.FromSlash(pattern[:strings.LastIndex(pattern, "/")+1]))
+	}
+	return filepath.Dir(filepath.FromSlash(pattern))
+}
+
+// Match reports whether the file at an absolute path matches the pattern
+func (g Glob) Match(path string) bool {
+	return utils.MatchGlobExact(g.abs(), path)
+}
+
+// abs returns the pattern as an absolute slash separated glob
+func (g Glob) abs() string {
+	if strings.HasPrefix(g.Pattern, "/") {
+		cwd, _ := os.Getwd()
+		return filepath.ToSlash(cwd) + g.Pattern
+	}
+	return filepath.ToSlash(filepath.Clean(filepath.Join(g.Dir, g.Pattern)))
+}
+
+// globPlugin expands import.meta.glob calls into objects of lazy dynamic
+// imports, or of eager imports with { eager: true }. onGlob, if set, is
+// called with every pattern so that watch mode can rebuild when a
+// matching file is added.
+func globPlugin(onGlob func(Glob)) api.Plugin {
+	return api.Plugin{
+		Name: "jspackr:glob",
+		Setup: func(build api.PluginBuild) {
+			build.OnLoad(api.OnLoadOptions{Filter: `\.[cm]?[jt]sx?$`, Namespace: "file"}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				if strings.Contains(filepath.ToSlash(args.Path), "/node_modules/") {
+					return api.OnLoadResult{}, nil
+				}
+				data, err := os.ReadFile(args.Path)
+				if err != nil || !strings.Contains(string(data), globCall) || len(findGlobCalls(string(data))) == 0 {
+					// Left to esbuild, which reports read errors itself
+					return api.OnLoadResult{}, nil
+				}
+
+				dir := filepath.Dir(args.Path)
+				contents, globs, msgs := expandGlobs(string(data), args.Path)
+				if len(msgs) > 0 {
+					return api.OnLoadResult{Errors: msgs}, nil
+				}
+				if onGlob != nil {
+					for _, g := range globs {
+						onGlob(g)
+					}
+				}
+				return api.OnLoadResult{
+					Contents:   &contents,
+					Loader:     loaderFor(args.Path),
+					ResolveDir: dir,
+					WatchDirs:  globRoots(globs),
+				}, nil
+			})
+		},
+	}
+}
+
+// loaderFor returns the esbuild loader for a script file
+func loaderFor(path string) api.Loader {
+	switch strings.TrimPrefix(filepath.Ext(path), ".") {
+	case "jsx":
+		return api.LoaderJSX
+	case "ts", "mts", "cts":
+		return api.LoaderTS
+	case "tsx":
+		return api.LoaderTSX
+	default:
+		return api.LoaderJS
+	}
+}
+
+// globRoots returns the directories the globs list files in
+func globRoots(globs []Glob) []string {
+	var roots []string
+	for _, g := range globs {
+		roots = append(roots, g.Root())
+	}
+	return roots
+}
+
+// globOptions are the options of an import.meta.glob call
+type globOptions struct {
+	eager  bool
+	export string // Import only this export instead of the module namespace
+}
+
+// syntaxError is an error at an offset in the source being expanded
+type syntaxError struct {
+	offset int
+	text   string
+}
+
+func (e *syntaxError) Error() string { return e.text }
+
+// expandGlobs replaces every import.meta.glob call in source, which is
+// the contents of the file at path. Eager imports are added to the first
+// line, after a hashbang if there is one, and each replacement keeps the
+// line count of the call, so that line numbers of the rest of the file
+// stay the same.
+func expandGlobs(source, path string) (string, []Glob, []api.Message) {
+	dir := filepath.Dir(path)
+	var (
+		b       strings.Builder
+		imports strings.Builder
+		globs   []Glob
+		msgs    []api.Message
+		last    int
+		n       int
+	)
+
+	for _, start := range findGlobCalls(source) {
+		if start < last {
+			// Inside the arguments of the previous call
+			continue
+		}
+		end, patterns, opts, err := parseGlobCall(source, start+len(globCall))
+		if err != nil {
+			offset := start
+			var located *syntaxError
+			if errors.As(err, &located) {
+				offset = located.offset
+			}
+			msgs = append(msgs, locatedMessage(source, path, offset, err.Error()))
+			continue
+		}
+
+		var include, exclude []Glob
+		for _, p := range patterns {
+			if rest, negated := strings.CutPrefix(p, "!"); negated {
+				exclude = append(exclude, Glob{Dir: dir, Pattern: rest})
+			} else {
+				include = append(include, Glob{Dir: dir, Pattern: p})
+			}
+		}
+		globs = append(globs, include...)
+
+		var entries []string
+		for _, file := range globFiles(include, exclude, path) {
+			key := globKey(file, dir, include)
+			spec := strconv.Quote(importSpecifier(file, dir))
+			switch {
+			case opts.eager:
+				name := fmt.Sprintf("__glob_%d", n)
+				n++
+				if opts.export != "" {
+					fmt.Fprintf(&imports, "import { %s as %s } from %s; ", opts.export, name, spec)
+				} else {
+					fmt.Fprintf(&imports, "import * as %s from %s; ", name, spec)
+				}
+				entries = append(entries, fmt.Sprintf("%s: %s", strconv.Quote(key), name))
+			case opts.export != "":
+				entries = append(entries, fmt.Sprintf("%s: () => import(%s).then((m) => m[%s])", strconv.Quote(key), spec, strconv.Quote(opts.export)))
+			default:
+				entries = append(entries, fmt.Sprintf("%s: () => import(%s)", strconv.Quote(key), spec))
+			}
+		}
+
+		b.WriteString(source[last:start])
+		b.WriteString("{" + strings.Join(entries, ", ") + "}")
+		b.WriteString(strings.Repeat("\n", strings.Count(source[start:end], "\n")))
+		last = end
+	}
+	b.WriteString(source[last:])
+
+	if len(msgs) > 0 {
+		return "", nil, msgs
+	}
+
+	// Imports go after a hashbang, which must stay the first line
+	out := b.String()
+	if strings.HasPrefix(out, "#!") {
+		if i := strings.IndexByte(out, '\n'); i >= 0 {
+			return out[:i+1] + imports.String() + out[i+1:], globs, nil
+		}
+		return out + "\n" + imports.String(), globs, nil
+	}
+	return imports.String() + out, globs, nil
+}
+
+// findGlobCalls returns the offsets of the import.meta.glob calls in
+// source, skipping comments, strings, template literal text and regular
+// expression literals
+func findGlobCalls(source string) []int {
+	var calls []int
+	// braces counts the open braces of each template literal substitution
+	// being scanned, innermost last
+	var braces []int
+
+	for i := 0; i < len(source); i++ {
+		if end := skipComment(source, i); end >= 0 {
+			i = end
+			continue
+		}
+		c := source[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipString(source, i, c)
+		case c == '`':
+			i = skipTemplate(source, i)
+			if i < len(source) && source[i] == '{' {
+				// Stopped at a substitution, which is code
+				braces = append(braces, 0)
+			}
+		case c == '/' && startsRegexp(source[:i]):
+			i = skipRegexp(source, i)
+		case c == '{' && len(braces) > 0:
+			braces[len(braces)-1]++
+		case c == '}' && len(braces) > 0:
+			if braces[len(braces)-1] > 0 {
+				braces[len(braces)-1]--
+				break
+			}
+			// End of a substitution: continue with the template text
+			braces = braces[:len(braces)-1]
+			i = skipTemplate(source, i)
+			if i < len(source) && source[i] == '{' {
+				braces = append(braces, 0)
+			}
+		case strings.HasPrefix(source[i:], globCall) && (i == 0 || !isIdentChar(source[i-1]) && source[i-1] != '.'):
+			calls = append(calls, i)
+			i += len(globCall) - 1
+		}
+	}
+	return calls
+}
+
+// skipComment returns the offset of the last character of the comment
+// starting at i, or -1 if no comment starts there
+func skipComment(source string, i int) int {
+	if source[i] != '/' || i+1 >= len(source) {
+		return -1
+	}
+	switch source[i+1] {
+	case '/':
+		if end := strings.IndexByte(source[i:], '\n'); end >= 0 {
+			return i + end
+		}
+		return len(source)
+	case '*':
+		if end := strings.Index(source[i+2:], "*/"); end >= 0 {
+			return i + 2 + end + 1
+		}
+		return len(source)
+	}
+	return -1
+}
+
+// skipSpace returns the offset of the first character at or after pos
+// that is neither white space nor part of a comment
+func skipSpace(source string, pos int) int {
+	for pos < len(source) {
+		if end := skipComment(source, pos); end >= 0 {
+			pos = end + 1
+			continue
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(source[pos])) {
+			break
+		}
+		pos++
+	}
+	return pos
+}
+
+// stringLiteral returns the text of the string literal at pos and the
+// offset after it. Template literals with substitutions are not strings.
+func stringLiteral(source string, pos int) (string, int, bool) {
+	if pos >= len(source) {
+		return "", pos, false
+	}
+	var end int
+	switch quote := source[pos]; quote {
+	case '\'', '"':
+		end = skipString(source, pos, quote)
+	case '`':
+		end = skipTemplate(source, pos)
+	default:
+		return "", pos, false
+	}
+	if end >= len(source) || source[end] != source[pos] {
+		return "", pos, false
+	}
+	return source[pos+1 : end], end + 1, true
+}
+
+// skipString returns the offset of the quote that closes the string
+// starting at i
+func skipString(source string, i int, quote byte) int {
+	for i++; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case quote, '\n':
+			return i
+		}
+	}
+	return i
+}
+
+// skipTemplate scans template literal text from the backtick or closing
+// brace at i. It returns the offset of the closing backtick, or of the
+// opening brace of the next substitution.
+func skipTemplate(source string, i int) int {
+	for i++; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case '`':
+			return i
+		case '$':
+			if i+1 < len(source) && source[i+1] == '{' {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+// skipRegexp returns the offset of the slash that closes the regular
+// expression literal starting at i
+func skipRegexp(source string, i int) int {
+	inClass := false
+	for i++; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if !inClass {
+				return i
+			}
+		case '\n':
+			return i
+		}
+	}
+	return i
+}
+
+// regexpKeywords are the keywords a regular expression literal can follow
+var regexpKeywords = []string{"return", "typeof", "instanceof", "in", "of", "new", "delete", "void", "throw", "case", "do", "else", "yield", "await"}
+
+// startsRegexp reports whether a slash following before starts a regular
+// expression literal rather than a division
+func startsRegexp(before string) bool {
+	before = strings.TrimRight(before, " \t\r\n")
+	if before == "" {
+		return true
+	}
+	prev := before[len(before)-1]
+	if strings.IndexByte("(,=:[!&|?{};+-*%<>~^", prev) >= 0 {
+		return true
+	}
+	if !isIdentChar(prev) {
+		return false
+	}
+	word := before[strings.LastIndexFunc(before, func(r rune) bool { return r > 0x7f || !isIdentChar(byte(r)) })+1:]
+	return slices.Contains(regexpKeywords, word)
+}
+
+// identEnd returns the offset after the identifier characters at pos
+func identEnd(source string, pos int) int {
+	for pos < len(source) && isIdentChar(source[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// isIdentChar reports whether c can be part of an identifier
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// parseGlobCall parses the arguments of an import.meta.glob call starting
+// at pos, just after the opening parenthesis. It returns the position
+// after the closing parenthesis.
+func parseGlobCall(source string, pos int) (int, []string, globOptions, error) {
+	var opts globOptions
+	errArgs := fmt.Errorf("import.meta.glob takes a string or an array of strings and an optional options object")
+
+	skip := func() {
+		pos = skipSpace(source, pos)
+	}
+	str := func() (string, bool) {
+		s, end, ok := stringLiteral(source, pos)
+		if ok {
+			pos = end
+		}
+		return s, ok
+	}
+
+	var patterns []string
+	skip()
+	if pos < len(source) && source[pos] == '[' {
+		pos++
+		for {
+			skip()
+			if pos < len(source) && source[pos] == ']' {
+				pos++
+				break
+			}
+			s, ok := str()
+			if !ok {
+				return 0, nil, opts, errArgs
+			}
+			patterns = append(patterns, s)
+			skip()
+			if pos < len(source) && source[pos] == ',' {
+				pos++
+			}
+		}
+	} else {
+		s, ok := str()
+		if !ok {
+			return 0, nil, opts, errArgs
+		}
+		patterns = append(patterns, s)
+	}
+	if len(patterns) == 0 {
+		return 0, nil, opts, errArgs
+	}
+
+	skip()
+	if pos < len(source) && source[pos] == ',' {
+		pos++
+		skip()
+	}
+	if pos < len(source) && source[pos] == '{' {
+		var err error
+		pos, opts, err = parseGlobOptions(source, pos)
+		if err != nil {
+			return 0, nil, opts, err
+		}
+		skip()
+		if pos < len(source) && source[pos] == ',' {
+			pos++
+			skip()
+		}
+	}
+	if pos >= len(source) || source[pos] != ')' {
+		return 0, nil, opts, errArgs
+	}
+	return pos + 1, patterns, opts, nil
+}
+
+// parseGlobOptions parses the options object of an import.meta.glob call
+// starting at the opening brace at pos. It returns the position after the
+// closing brace. Options other than eager and import, and values other
+// than literals, are errors rather than being ignored.
+func parseGlobOptions(source string, pos int) (int, globOptions, error) {
+	var opts globOptions
+	errObject := fmt.Errorf("import.meta.glob options must be an object literal")
+
+	for pos++; ; {
+		pos = skipSpace(source, pos)
+		if pos >= len(source) {
+			return 0, opts, errObject
+		}
+		if source[pos] == '}' {
+			return pos + 1, opts, nil
+		}
+
+		keyStart := pos
+		key, end, ok := stringLiteral(source, pos)
+		if !ok {
+			end = identEnd(source, pos)
+			key = source[pos:end]
+		}
+		if key == "" {
+			return 0, opts, &syntaxError{pos, errObject.Error()}
+		}
+		pos = skipSpace(source, end)
+		if pos >= len(source) || source[pos] != ':' {
+			return 0, opts, &syntaxError{pos, errObject.Error()}
+		}
+		pos = skipSpace(source, pos+1)
+
+		switch key {
+		case "eager":
+			word := identEnd(source, pos)
+			switch source[pos:word] {
+			case "true":
+				opts.eager = true
+			case "false":
+				opts.eager = false
+			default:
+				return 0, opts, &syntaxError{pos, "import.meta.glob option eager must be true or false"}
+			}
+			pos = word
+		case "import":
+			name, end, ok := stringLiteral(source, pos)
+			if !ok || name == "" || strings.IndexFunc(name, func(r rune) bool { return r < 0x80 && !isIdentChar(byte(r)) }) >= 0 {
+				return 0, opts, &syntaxError{pos, "import.meta.glob option import must be a string naming an export"}
+			}
+			opts.export = name
+			pos = end
+		default:
+			return 0, opts, &syntaxError{keyStart, fmt.Sprintf("import.meta.glob option %q is not supported; the options are eager and import", key)}
+		}
+
+		pos = skipSpace(source, pos)
+		switch {
+		case pos < len(source) && source[pos] == ',':
+			pos++
+		case pos < len(source) && source[pos] == '}':
+		default:
+			return 0, opts, &syntaxError{pos, errObject.Error()}
+		}
+	}
+}
+
+// globFiles lists the files matching any of include and none of exclude,
+// sorted and without the importing file itself
+func globFiles(include, exclude []Glob, importer string) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, g := range include {
+		filepath.WalkDir(g.Root(), func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != g.Root() && (d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if seen[path] || path == importer || !g.Match(path) {
+				return nil
+			}
+			for _, x := range exclude {
+				if x.Match(path) {
+					return nil
+				}
+			}
+			seen[path] = true
+			files = append(files, path)
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// globKey returns the key of a file in the expanded object: its path as
+// the matching pattern spells it, relative to the importer or, for
+// patterns with a leading slash, to the working directory
+func globKey(file, dir string, include []Glob) string {
+	for _, g := range include {
+		if strings.HasPrefix(g.Pattern, "/") && g.Match(file) {
+			cwd, _ := os.Getwd()
+			if rel, err := filepath.Rel(cwd, file); err == nil {
+				return "/" + filepath.ToSlash(rel)
+			}
+		}
+	}
+	return importSpecifier(file, dir)
+}
+
+// importSpecifier returns a relative import specifier for file from dir
+func importSpecifier(file, dir string) string {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+// locatedMessage returns an error message pointing at offset in source
+func locatedMessage(source, path string, offset int, text string) api.Message {
+	line := strings.Count(source[:offset], "\n") + 1
+	lineStart := strings.LastIndexByte(source[:offset], '\n') + 1
+	lineEnd := strings.IndexByte(source[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(source) - offset
+	}
+	cwd, _ := os.Getwd()
+	if rel, err := filepath.Rel(cwd, path); err == nil {
+		path = rel
+	}
+	return api.Message{Text: text, Location: &api.Location{
+		File:     filepath.ToSlash(path),
+		Line:     line,
+		Column:   offset - lineStart,
+		LineText: source[lineStart : offset+lineEnd],
+	}}
+}
@@ -0,0 +1,200 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindGlobCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   int
+	}{
+		{"call", `const m = import.meta.glob("./*.js");`, 1},
+		{"two calls", "import.meta.glob('./a/*.js');\nimport.meta.glob('./b/*.js');", 2},
+		{"line comment", "// pages are loaded with import.meta.glob(somePattern)\n", 0},
+		{"block comment", "/* import.meta.glob('./*.js') */ x();", 0},
+		{"double quoted string", `const s = "import.meta.glob('./*.js')";`, 0},
+		{"single quoted string", `const s = 'import.meta.glob("./*.js")';`, 0},
+		{"escaped quote", `const s = 'it\'s import.meta.glob("./*.js")';`, 0},
+		{"template text", "const s = `import.meta.glob('./*.js')`;", 0},
+		{"template substitution", "const s = `${import.meta.glob('./*.js')}`;", 1},
+		{"nested template", "const s = `a${`b${import.meta.glob('./*.js')}`}c`; import.meta.glob('./x/*.js');", 2},
+		{"braces in substitution", "const s = `${{a: 1}.a} import.meta.glob(x)`;", 0},
+		{"regexp", `const re = /import.meta.glob\(/; import.meta.glob("./*.js");`, 1},
+		{"regexp after return", "function f() { return /'/.test(x) }\nimport.meta.glob('./*.js');", 1},
+		{"division", "const x = a / b; import.meta.glob('./*.js'); const y = c / d;", 1},
+		{"member of something else", `foo.import.meta.glob("./*.js");`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findGlobCalls(tt.source); len(got) != tt.want {
+				t.Errorf("findGlobCalls(%q) found %d calls at %v, want %d", tt.source, len(got), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGlobCall(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     string
+		patterns []string
+		opts     globOptions
+		rest     string
+		wantErr  bool
+	}{
+		{name: "string", args: `"./pages/*.js");`, patterns: []string{"./pages/*.js"}, rest: ";"},
+		{name: "single quotes", args: `'./*.ts')`, patterns: []string{"./*.ts"}},
+		{name: "backticks", args: "`./*.ts`)", patterns: []string{"./*.ts"}},
+		{name: "array", args: `["./a/*.js", '!./a/_*.js',])`, patterns: []string{"./a/*.js", "!./a/_*.js"}},
+		{name: "eager", args: `"./*.js", { eager: true })`, patterns: []string{"./*.js"}, opts: globOptions{eager: true}},
+		{name: "import", args: `"./*.js", { import: 'default' })`, patterns: []string{"./*.js"}, opts: globOptions{export: "default"}},
+		{name: "multiline", args: "\n  './*.js',\n  {\n    eager: true,\n    import: \"title\",\n  },\n)", patterns: []string{"./*.js"}, opts: globOptions{eager: true, export: "title"}},
+		{name: "eager false", args: `"./*.js", { eager: false })`, patterns: []string{"./*.js"}},
+		{name: "quoted keys", args: `"./*.js", { "eager": true, 'import': 'title' })`, patterns: []string{"./*.js"}, opts: globOptions{eager: true, export: "title"}},
+		{name: "commented option", args: "\"./*.js\", { /* eager: true */ // eager: true\n })", patterns: []string{"./*.js"}},
+		{name: "nested eager", args: `"./*.js", { query: { eager: true } })`, wantErr: true},
+		{name: "eager expression", args: `"./*.js", { eager: !0 })`, wantErr: true},
+		{name: "unknown option", args: `"./*.js", { as: 'raw' })`, wantErr: true},
+		{name: "import not a name", args: `"./*.js", { import: 'a.b' })`, wantErr: true},
+		{name: "unclosed options", args: `"./*.js", { eager: true )`, wantErr: true},
+		{name: "variable", args: `pattern)`, wantErr: true},
+		{name: "substitution", args: "`./${dir}/*.js`)", wantErr: true},
+		{name: "empty array", args: `[])`, wantErr: true},
+		{name: "unclosed", args: `"./*.js"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := globCall + tt.args
+			end, patterns, opts, err := parseGlobCall(source, len(globCall))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseGlobCall(%q) succeeded, want an error", source)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGlobCall(%q): %v", source, err)
+			}
+			if !reflect.DeepEqual(patterns, tt.patterns) {
+				t.Errorf("patterns = %q, want %q", patterns, tt.patterns)
+			}
+			if opts != tt.opts {
+				t.Errorf("options = %+v, want %+v", opts, tt.opts)
+			}
+			if rest := source[end:]; rest != tt.rest {
+				t.Errorf("rest after call = %q, want %q", rest, tt.rest)
+			}
+		})
+	}
+}
+
+// globProject creates a directory with an importing file and pages
+func globProject(t *testing.T) (dir, importer string) {
+	t.Helper()
+	dir = t.TempDir()
+	for _, name := range []string{"pages/a.js", "pages/b.js", "pages/_draft.js", "pages/sub/c.js", "pages/notes.md"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("export default 1;\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, filepath.Join(dir, "index.js")
+}
+
+func TestExpandGlobsLazy(t *testing.T) {
+	_, importer := globProject(t)
+	source := "const pages = import.meta.glob('./pages/*.js');\n"
+
+	got, globs, msgs := expandGlobs(source, importer)
+	if len(msgs) > 0 {
+		t.Fatalf("unexpected errors: %v", msgs)
+	}
+	want := `const pages = {"./pages/_draft.js": () => import("./pages/_draft.js"), "./pages/a.js": () => import("./pages/a.js"), "./pages/b.js": () => import("./pages/b.js")};` + "\n"
+	if got != want {
+		t.Errorf("expandGlobs =\n%s\nwant\n%s", got, want)
+	}
+	if len(globs) != 1 || globs[0].Pattern != "./pages/*.js" {
+		t.Errorf("globs = %+v, want the one pattern", globs)
+	}
+}
+
+func TestExpandGlobsEager(t *testing.T) {
+	_, importer := globProject(t)
+	source := "#!/usr/bin/env node\nconst pages = import.meta.glob(\n  ['./pages/**/*.js', '!./pages/_*.js'],\n  { eager: true, import: 'default' },\n);\nconsole.log(pages);\n"
+
+	got, _, msgs := expandGlobs(source, importer)
+	if len(msgs) > 0 {
+		t.Fatalf("unexpected errors: %v", msgs)
+	}
+	lines := strings.Split(got, "\n")
+	if lines[0] != "#!/usr/bin/env node" {
+		t.Errorf("first line = %q, want the hashbang", lines[0])
+	}
+	for _, spec := range []string{`import { default as __glob_0 } from "./pages/a.js";`, `"./pages/sub/c.js": __glob_2`} {
+		if !strings.Contains(got, spec) {
+			t.Errorf("output lacks %q:\n%s", spec, got)
+		}
+	}
+	if strings.Contains(got, "_draft") {
+		t.Errorf("output includes the excluded file:\n%s", got)
+	}
+	if strings.Count(got, "\n") != strings.Count(source, "\n") {
+		t.Errorf("line count changed from %d to %d:\n%s", strings.Count(source, "\n"), strings.Count(got, "\n"), got)
+	}
+	if lines[5] != "console.log(pages);" {
+		t.Errorf("line 6 = %q, want it unchanged", lines[5])
+	}
+}
+
+func TestExpandGlobsSkipsComments(t *testing.T) {
+	_, importer := globProject(t)
+	source := "// pages are loaded with import.meta.glob(somePattern)\nconst s = \"import.meta.glob(x)\";\n"
+
+	got, globs, msgs := expandGlobs(source, importer)
+	if len(msgs) > 0 {
+		t.Fatalf("unexpected errors: %v", msgs)
+	}
+	if got != source || len(globs) != 0 {
+		t.Errorf("expandGlobs changed source without calls:\n%s", got)
+	}
+}
+
+func TestExpandGlobsError(t *testing.T) {
+	_, importer := globProject(t)
+	source := "const p = './pages/*.js';\nconst m = import.meta.glob(p);\n"
+
+	_, _, msgs := expandGlobs(source, importer)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(msgs))
+	}
+	loc := msgs[0].Location
+	if loc == nil || loc.Line != 2 || loc.Column != 10 || loc.LineText != "const m = import.meta.glob(p);" {
+		t.Errorf("location = %+v, want line 2, column 10", loc)
+	}
+}
+
+func TestExpandGlobsUnknownOption(t *testing.T) {
+	_, importer := globProject(t)
+	source := "const m = import.meta.glob('./pages/*.js', {\n  eager: true,\n  query: '?raw',\n});\n"
+
+	_, _, msgs := expandGlobs(source, importer)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(msgs))
+	}
+	if !strings.Contains(msgs[0].Text, `"query" is not supported`) {
+		t.Errorf("error = %q, want it to name the query option", msgs[0].Text)
+	}
+	loc := msgs[0].Location
+	if loc == nil || loc.Line != 3 || loc.Column != 2 {
+		t.Errorf("location = %+v, want line 3, column 2", loc)
+	}
+}
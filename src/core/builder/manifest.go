@@ -0,0 +1,117 @@
+package builder
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// Manifest lists the files a build wrote, so that servers and deploy
+// scripts can find them without globbing the output directory. Paths are
+// relative to the directory of the manifest file.
+type Manifest struct {
+	Outputs []ManifestOutput `json:"outputs"`
+	Public  []string         `json:"public,omitempty"` // Files copied from the public directory
+}
+
+// ManifestOutput is a bundle file with the entry point it was built from
+type ManifestOutput struct {
+	Path       string `json:"path"`
+	EntryPoint string `json:"entryPoint,omitempty"`
+	Bytes      int64  `json:"bytes"`
+}
+
+// writeManifest saves the manifest of a finished build to opts.Manifest.
+// Dry runs write nothing.
+func writeManifest(opts Options, result api.BuildResult, public []PublicFile) error {
+	if opts.Manifest == "" || opts.DryRun {
+		return nil
+	}
+	entries := make(map[string]string)
+	if meta, err := ParseMetafile(result.Metafile); err == nil {
+		for path, out := range meta.Outputs {
+			if abs, err := filepath.Abs(path); err == nil && out.EntryPoint != "" {
+				entries[abs] = out.EntryPoint
+			}
+		}
+	}
+
+	var m Manifest
+	for _, file := range result.OutputFiles {
+		m.Outputs = append(m.Outputs, ManifestOutput{
+			Path:       manifestPath(opts.Manifest, file.Path),
+			EntryPoint: entries[file.Path],
+			Bytes:      int64(len(file.Contents)),
+		})
+	}
+	sort.Slice(m.Outputs, func(i, j int) bool {
+		return m.Outputs[i].Path < m.Outputs[j].Path
+	})
+	m.Public = manifestPublic(opts.Manifest, public)
+	return saveManifest(opts.Manifest, &m)
+}
+
+// UpdateManifestPublic replaces the public files listed in the manifest
+// of opts, if it has one, after the public directory was synced without
+// a rebuild
+func UpdateManifestPublic(opts Options, public []PublicFile) error {
+	if opts.Manifest == "" || opts.DryRun {
+		return nil
+	}
+	data, err := os.ReadFile(opts.Manifest)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	m.Public = manifestPublic(opts.Manifest, public)
+	return saveManifest(opts.Manifest, &m)
+}
+
+// manifestPublic returns the sorted manifest paths of public files
+func manifestPublic(manifest string, public []PublicFile) []string {
+	paths := make([]string, 0, len(public))
+	for _, file := range public {
+		paths = append(paths, manifestPath(manifest, file.Path))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// manifestPath returns path relative to the directory of the manifest,
+// with forward slashes
+func manifestPath(manifest, path string) string {
+	dir, err := filepath.Abs(filepath.Dir(manifest))
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// saveManifest writes m as indented JSON
+func saveManifest(path string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,77 @@
+package builder
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// MetaFile represents the structure of the metadata file
+type MetaFile struct {
+	Inputs  map[string]MetaInput  `json:"inputs"`
+	Outputs map[string]MetaOutput `json:"outputs"`
+}
+
+// MetaInput is a source file read by the build
+type MetaInput struct {
+	Bytes   int          `json:"bytes"`
+	Imports []MetaImport `json:"imports"`
+}
+
+// MetaImport is an import of one input by another. Kind is esbuild's
+// import kind, such as "import-statement", "dynamic-import" or
+// "require-call". Path is the resolved input, unless External is set.
+type MetaImport struct {
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+	External bool   `json:"external"`
+	Original string `json:"original"`
+}
+
+// MetaOutput is a file written by the build
+type MetaOutput struct {
+	Bytes      int                        `json:"bytes"`
+	EntryPoint string                     `json:"entryPoint"`
+	Inputs     map[string]MetaOutputInput `json:"inputs"`
+	Exports    []string                   `json:"exports"`
+}
+
+// MetaOutputInput is the part of an output contributed by one input,
+// after tree shaking and minification
+type MetaOutputInput struct {
+	BytesInOutput int `json:"bytesInOutput"`
+}
+
+// ParseMetafile decodes the metafile JSON produced by esbuild
+func ParseMetafile(meta string) (*MetaFile, error) {
+	var m MetaFile
+	if err := json.Unmarshal([]byte(meta), &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// LoadMetafile reads a metafile saved to disk
+func LoadMetafile(path string) (*MetaFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMetafile(string(data))
+}
+
+// MergeMetafiles combines the metafiles of several builds into one
+func MergeMetafiles(metas ...*MetaFile) *MetaFile {
+	merged := &MetaFile{
+		Inputs:  make(map[string]MetaInput),
+		Outputs: make(map[string]MetaOutput),
+	}
+	for _, m := range metas {
+		for path, in := range m.Inputs {
+			merged.Inputs[path] = in
+		}
+		for path, out := range m.Outputs {
+			merged.Outputs[path] = out
+		}
+	}
+	return merged
+}
@@ -0,0 +1,77 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RunAll builds every target in parallel and prints a combined report.
+// It returns an error describing every failed target.
+func RunAll(targets []Options) error {
+	if len(targets) == 1 {
+		return Run(targets[0])
+	}
+
+	start := time.Now()
+	results, errs := BuildAll(targets)
+	elapsed := time.Since(start)
+
+	var succeeded []BuildResult
+	for i, result := range results {
+		if errs[i] == nil {
+			succeeded = append(succeeded, result)
+		}
+	}
+	PrintCombinedReport(succeeded, elapsed)
+
+	for i, result := range results {
+		if errs[i] == nil {
+			errs[i] = afterBuild(targets[i], result)
+		}
+	}
+
+	return joinTargetErrors(targets, errs)
+}
+
+// BuildAll builds every target in parallel without printing anything.
+// Results and errors are returned in the same order as targets.
+func BuildAll(targets []Options) ([]BuildResult, []error) {
+	results := make([]BuildResult, len(targets))
+	errs := make([]error, len(targets))
+
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = Build(targets[i])
+		}(i)
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+// joinTargetErrors combines per-target errors into a single error
+func joinTargetErrors(targets []Options, errs []error) error {
+	var failed []error
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", targets[i].Name, err))
+		}
+	}
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return failed[0]
+	default:
+		msg := fmt.Sprintf("%d of %d builds failed", len(failed), len(targets))
+		for _, err := range failed {
+			msg += "\n  " + err.Error()
+		}
+		return errors.New(msg)
+	}
+}
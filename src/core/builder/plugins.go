@@ -0,0 +1,206 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/core/plugin"
+)
+
+// loaders maps the loader names plugins use to esbuild loaders
+var loaders = map[string]api.Loader{
+	"":        api.LoaderNone,
+	"js":      api.LoaderJS,
+	"jsx":     api.LoaderJSX,
+	"ts":      api.LoaderTS,
+	"tsx":     api.LoaderTSX,
+	"css":     api.LoaderCSS,
+	"json":    api.LoaderJSON,
+	"text":    api.LoaderText,
+	"base64":  api.LoaderBase64,
+	"dataurl": api.LoaderDataURL,
+	"file":    api.LoaderFile,
+	"binary":  api.LoaderBinary,
+	"copy":    api.LoaderCopy,
+	"empty":   api.LoaderEmpty,
+}
+
+// resolveKinds names esbuild's import kinds for plugins
+var resolveKinds = map[api.ResolveKind]string{
+	api.ResolveEntryPoint:        "entry-point",
+	api.ResolveJSImportStatement: "import-statement",
+	api.ResolveJSRequireCall:     "require-call",
+	api.ResolveJSDynamicImport:   "dynamic-import",
+	api.ResolveJSRequireResolve:  "require-resolve",
+	api.ResolveCSSImportRule:     "import-rule",
+	api.ResolveCSSComposesFrom:   "composes-from",
+	api.ResolveCSSURLToken:       "url-token",
+}
+
+// pluginWarning prints a warning of an external plugin to stderr, which
+// stays clean when commands print to stdout
+func pluginWarning(msg string) {
+	fmt.Fprintf(os.Stderr, "%s %s\n", cli.IconsDefault.Warn, msg)
+}
+
+// userPlugins looks up the configured plugins in the registry, or runs
+// them as external processes when they have a command
+func userPlugins(opts Options) ([]api.Plugin, error) {
+	plugins := make([]api.Plugin, 0, len(opts.Plugins))
+	for _, cfg := range opts.Plugins {
+		if len(cfg.Command) > 0 {
+			plugins = append(plugins, esbuildPlugin(plugin.External(cfg.Name, cfg.Command, pluginWarning), cfg, opts))
+			continue
+		}
+		p, ok := plugin.Lookup(cfg.Name)
+		if !ok {
+			names := plugin.Names()
+			if len(names) == 0 {
+				return nil, fmt.Errorf("unknown plugin %q: this jspackr binary has no plugins compiled in", cfg.Name)
+			}
+			return nil, fmt.Errorf("unknown plugin %q: available plugins are %s", cfg.Name, strings.Join(names, ", "))
+		}
+		plugins = append(plugins, esbuildPlugin(p, cfg, opts))
+	}
+	return plugins, nil
+}
+
+// esbuildPlugin adapts a jspackr plugin to esbuild
+func esbuildPlugin(p plugin.Plugin, cfg config.PluginConfig, opts Options) api.Plugin {
+	info := plugin.Info{
+		Name:     opts.Name,
+		Input:    opts.Input,
+		Output:   opts.Output,
+		Format:   opts.Format,
+		Platform: opts.Platform,
+		Minify:   opts.Minify,
+	}
+	return api.Plugin{
+		Name: p.Name,
+		Setup: func(build api.PluginBuild) {
+			b := &pluginBuild{build: build, info: info}
+			if err := p.Setup(b, cfg.Options); err != nil {
+				// esbuild setups cannot fail, so the error fails the build on start
+				build.OnStart(func() (api.OnStartResult, error) {
+					return api.OnStartResult{}, fmt.Errorf("setup: %w", err)
+				})
+			}
+		},
+	}
+}
+
+// pluginBuild implements plugin.Build on top of esbuild
+type pluginBuild struct {
+	build api.PluginBuild
+	info  plugin.Info
+}
+
+func (b *pluginBuild) Info() plugin.Info {
+	return b.info
+}
+
+func (b *pluginBuild) OnStart(fn func() error) {
+	b.build.OnStart(func() (api.OnStartResult, error) {
+		return api.OnStartResult{}, fn()
+	})
+}
+
+func (b *pluginBuild) OnResolve(filter plugin.Filter, fn func(plugin.ResolveArgs) (plugin.ResolveResult, error)) {
+	opts := api.OnResolveOptions{Filter: filter.Filter, Namespace: filter.Namespace}
+	b.build.OnResolve(opts, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+		res, err := fn(plugin.ResolveArgs{
+			Path:       args.Path,
+			Importer:   args.Importer,
+			Namespace:  args.Namespace,
+			ResolveDir: args.ResolveDir,
+			Kind:       resolveKinds[args.Kind],
+			PluginData: args.PluginData,
+		})
+		if err != nil {
+			return api.OnResolveResult{}, err
+		}
+		return api.OnResolveResult{
+			Path:       res.Path,
+			Namespace:  res.Namespace,
+			External:   res.External,
+			PluginData: res.PluginData,
+			Errors:     esbuildMessages(res.Errors),
+			Warnings:   esbuildMessages(res.Warnings),
+		}, nil
+	})
+}
+
+func (b *pluginBuild) OnLoad(filter plugin.Filter, fn func(plugin.LoadArgs) (plugin.LoadResult, error)) {
+	opts := api.OnLoadOptions{Filter: filter.Filter, Namespace: filter.Namespace}
+	b.build.OnLoad(opts, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+		res, err := fn(plugin.LoadArgs{
+			Path:       args.Path,
+			Namespace:  args.Namespace,
+			Suffix:     args.Suffix,
+			PluginData: args.PluginData,
+		})
+		if err != nil {
+			return api.OnLoadResult{}, err
+		}
+		loader, ok := loaders[res.Loader]
+		if !ok {
+			return api.OnLoadResult{}, fmt.Errorf("unknown loader %q for %s", res.Loader, args.Path)
+		}
+		return api.OnLoadResult{
+			Contents:   res.Contents,
+			Loader:     loader,
+			ResolveDir: res.ResolveDir,
+			PluginData: res.PluginData,
+			WatchFiles: res.WatchFiles,
+			Errors:     esbuildMessages(res.Errors),
+			Warnings:   esbuildMessages(res.Warnings),
+		}, nil
+	})
+}
+
+func (b *pluginBuild) OnEnd(fn func(plugin.EndResult) error) {
+	b.build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+		return api.OnEndResult{}, fn(plugin.EndResult{
+			Errors:   pluginMessages(result.Errors),
+			Warnings: pluginMessages(result.Warnings),
+			Metafile: result.Metafile,
+		})
+	})
+}
+
+// esbuildMessages converts plugin messages, keeping their locations so
+// that errors are reported like any other build error
+func esbuildMessages(msgs []plugin.Message) []api.Message {
+	out := make([]api.Message, len(msgs))
+	for i, msg := range msgs {
+		out[i] = api.Message{Text: msg.Text}
+		if msg.File != "" {
+			out[i].Location = &api.Location{
+				File:     msg.File,
+				Line:     msg.Line,
+				Column:   msg.Column,
+				LineText: msg.LineText,
+			}
+		}
+	}
+	return out
+}
+
+// pluginMessages converts esbuild messages for plugins
+func pluginMessages(msgs []api.Message) []plugin.Message {
+	out := make([]plugin.Message, len(msgs))
+	for i, msg := range msgs {
+		out[i] = plugin.Message{Text: msg.Text}
+		if loc := msg.Location; loc != nil {
+			out[i].File = loc.File
+			out[i].Line = loc.Line
+			out[i].Column = loc.Column
+			out[i].LineText = loc.LineText
+		}
+	}
+	return out
+}
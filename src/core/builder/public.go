@@ -0,0 +1,267 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PublicFile is a file copied unchanged from the public directory
+type PublicFile struct {
+	Path   string // Destination of the copy
+	Bytes  int64
+	Copied bool // False if the destination was already up to date
+}
+
+// PublicRecordDir keeps, per output directory, the files copied there
+// from the public directory, so that a copy never overwrites a file it
+// did not write itself
+const PublicRecordDir = ".jspackr/public"
+
+// CopyPublic copies the public directory into the output directory. Files
+// whose copy has the same size and modification time are skipped, so
+// repeated calls only copy what changed. A missing public directory, or
+// an output directory inside it, means there is nothing to copy. outputs
+// lists the files the bundle writes; a public file that would overwrite
+// one of them, or any other file the copy did not write, fails the copy.
+func CopyPublic(opts Options, outputs []string) ([]PublicFile, error) {
+	src, dst, ok, err := publicDirs(opts)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	bundle := make(map[string]bool, len(outputs))
+	for _, out := range outputs {
+		if abs, err := filepath.Abs(out); err == nil {
+			bundle[abs] = true
+		}
+	}
+	record := loadPublicRecord(dst)
+
+	var files []PublicFile
+	err = filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if bundle[target] {
+			return fmt.Errorf("public file %s conflicts with bundle output %s", displayPath(path), displayPath(target))
+		}
+		key := filepath.ToSlash(rel)
+		if !record[key] && !upToDate(path, target) {
+			if _, err := os.Lstat(target); err == nil {
+				return fmt.Errorf("public file %s conflicts with %s, which was not copied from the public directory", displayPath(path), displayPath(target))
+			}
+		}
+		file, err := copyIfChanged(path, target)
+		if err != nil {
+			return err
+		}
+		record[key] = true
+		file.Path = displayPath(target)
+		files = append(files, file)
+		return nil
+	})
+	if saveErr := savePublicRecord(dst, record); err == nil {
+		err = saveErr
+	}
+	return files, err
+}
+
+// SyncPublic copies what changed in the public directory in watch mode,
+// between builds. Conflicts are checked against the files a build of
+// opts is expected to write.
+func SyncPublic(opts Options) ([]PublicFile, error) {
+	outputs := []string{opts.Output, opts.Output + ".map"}
+	css := strings.TrimSuffix(opts.Output, filepath.Ext(opts.Output)) + ".css"
+	outputs = append(outputs, css, css+".map")
+	if opts.Compress {
+		for _, out := range outputs {
+			outputs = append(outputs, out+".gz", out+".br")
+		}
+	}
+	if opts.Manifest != "" {
+		outputs = append(outputs, opts.Manifest)
+	}
+	return CopyPublic(opts, outputs)
+}
+
+// RemovePublic deletes the copy of path, a file or directory that was
+// removed from the public directory
+func RemovePublic(opts Options, path string) error {
+	src, dst, ok, err := publicDirs(opts)
+	if err != nil || !ok {
+		return err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(src, abs)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+
+	// Only copies are removed; other files in the output directory stay
+	record := loadPublicRecord(dst)
+	prefix := filepath.ToSlash(rel)
+	for key := range record {
+		if key != prefix && !strings.HasPrefix(key, prefix+"/") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dst, filepath.FromSlash(key))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		delete(record, key)
+	}
+	return savePublicRecord(dst, record)
+}
+
+// publicDirs returns the absolute public and output directories of opts.
+// ok is false if there is nothing to copy.
+func publicDirs(opts Options) (src, dst string, ok bool, err error) {
+	if opts.PublicDir == "" {
+		return "", "", false, nil
+	}
+	src, err = filepath.Abs(opts.PublicDir)
+	if err != nil {
+		return "", "", false, err
+	}
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		return "", "", false, nil
+	}
+	dst, err = filepath.Abs(filepath.Dir(opts.Output))
+	if err != nil {
+		return "", "", false, err
+	}
+	// Bundles written into the public directory are served from it
+	// already, and copying into it would copy the copies
+	if within(src, dst) {
+		return "", "", false, nil
+	}
+	// Copying into the project itself would spread the public tree over
+	// the sources next to it
+	if cwd, err := os.Getwd(); err == nil && within(dst, cwd) {
+		return "", "", false, fmt.Errorf("refusing to copy %s into %s: the output directory contains the working directory; set publicDir to \"\" to turn copying off", displayPath(src), displayPath(dst))
+	}
+	if within(dst, src) {
+		return "", "", false, fmt.Errorf("refusing to copy %s into %s: the output directory contains the public directory; set publicDir to \"\" to turn copying off", displayPath(src), displayPath(dst))
+	}
+	return src, dst, true, nil
+}
+
+// within reports whether path is dir or inside it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// upToDate reports whether dst is a copy of src made by copyIfChanged
+func upToDate(src, dst string) bool {
+	info, err := os.Stat(src)
+	if err != nil {
+		return false
+	}
+	existing, err := os.Stat(dst)
+	return err == nil && existing.Size() == info.Size() && existing.ModTime().Equal(info.ModTime())
+}
+
+// publicRecordPath returns where the copied files of dst are recorded
+func publicRecordPath(dst string) string {
+	rel := displayPath(dst)
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(filepath.Clean(rel))
+	return filepath.Join(PublicRecordDir, name+".json")
+}
+
+// loadPublicRecord returns the files copied into dst before that still
+// exist, keyed by slash separated path relative to dst
+func loadPublicRecord(dst string) map[string]bool {
+	record := make(map[string]bool)
+	data, err := os.ReadFile(publicRecordPath(dst))
+	if err != nil {
+		return record
+	}
+	var paths []string
+	if json.Unmarshal(data, &paths) != nil {
+		return record
+	}
+	for _, p := range paths {
+		if _, err := os.Lstat(filepath.Join(dst, filepath.FromSlash(p))); err == nil {
+			record[p] = true
+		}
+	}
+	return record
+}
+
+// savePublicRecord writes the files copied into dst
+func savePublicRecord(dst string, record map[string]bool) error {
+	paths := make([]string, 0, len(record))
+	for p := range record {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	data, err := json.MarshalIndent(paths, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := publicRecordPath(dst)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// copyIfChanged copies src to dst unless dst has the same size and
+// modification time, which the copy takes over from src
+func copyIfChanged(src, dst string) (PublicFile, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return PublicFile{}, err
+	}
+	file := PublicFile{Bytes: info.Size()}
+	if upToDate(src, dst) {
+		return file, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return PublicFile{}, err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return PublicFile{}, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return PublicFile{}, err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return PublicFile{}, err
+	}
+	if err := out.Close(); err != nil {
+		return PublicFile{}, err
+	}
+	if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+		return PublicFile{}, err
+	}
+	file.Copied = true
+	return file, nil
+}
+
+// displayPath returns path relative to the working directory if possible
+func displayPath(path string) string {
+	cwd, _ := os.Getwd()
+	if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
@@ -11,24 +11,22 @@ import (
 	"github.com/kalokaradia/jspackr/src/cli"
 )
 
-// MetaFile represents the structure of the metadata file
-type MetaFile struct {
-	Inputs  map[string]struct{ Bytes int `json:"bytes"` } `json:"inputs"`
-	Outputs map[string]struct{ Bytes int `json:"bytes"` } `json:"outputs"`
-}
-
 // BuildResult holds the build information for reporting
 type BuildResult struct {
+	Name        string
 	OutputPath  string
 	InputSize   int64
 	OutputSize  int64
 	ModuleCount int
 	Elapsed     time.Duration
 	Metafile    string
+	Report      bool // Print the detailed breakdown
+	Outputs     []OutputFile
+	Public      []PublicFile // Files copied from the public directory
 }
 
-// formatBytes formats bytes to human readable string
-func formatBytes(bytes int64) string {
+// FormatBytes formats bytes to human readable string
+func FormatBytes(bytes int64) string {
 	if bytes < 1024 {
 		return fmt.Sprintf("%d B", bytes)
 	}
@@ -50,15 +48,20 @@ func PrintReport(result BuildResult) {
 	if successIcon == "" {
 		successIcon = "✓"
 	}
-	cli.DefaultStyles.Value.Printf("  %s Build succeeded\n", successIcon)
+	if result.Name != "" {
+		cli.DefaultStyles.Value.Printf("  %s Build succeeded", successIcon)
+		cli.DefaultStyles.Highlight.Printf(" [%s]\n", result.Name)
+	} else {
+		cli.DefaultStyles.Value.Printf("  %s Build succeeded\n", successIcon)
+	}
 
 	// Output
 	cli.DefaultStyles.Key.Printf("  %s Output:", cli.IconsDefault.Space)
 	cli.DefaultStyles.Path.Printf(" %s\n", relPath)
 
 	// Size comparison
-	inputSizeStr := formatBytes(result.InputSize)
-	outputSizeStr := formatBytes(result.OutputSize)
+	inputSizeStr := FormatBytes(result.InputSize)
+	outputSizeStr := FormatBytes(result.OutputSize)
 	percent := float64(result.OutputSize) / float64(result.InputSize) * 100
 	arrow := cli.IconsDefault.ArrowRight
 	if arrow == "" {
@@ -68,6 +71,41 @@ func PrintReport(result BuildResult) {
 	cli.DefaultStyles.Key.Printf("  %s Size:", cli.IconsDefault.Space)
 	cli.DefaultStyles.Stats.Printf(" %s\n", sizeStr)
 
+	// Sizes over the wire, when they were measured
+	measured := compressed(result.Outputs)
+	if len(result.Outputs) == 1 && measured {
+		out := result.Outputs[0]
+		cli.DefaultStyles.Key.Printf("  %s Compressed:", cli.IconsDefault.Space)
+		cli.DefaultStyles.Stats.Printf(" %s gzip, %s brotli\n", FormatBytes(out.Gzip), FormatBytes(out.Brotli))
+	} else if len(result.Outputs) > 1 {
+		cli.DefaultStyles.Key.Printf("  %s Outputs:\n", cli.IconsDefault.Space)
+		if measured {
+			cli.DefaultStyles.Dim.Printf("    %-46s %10s %10s %10s\n", "", "size", "gzip", "brotli")
+		}
+		for _, out := range result.Outputs {
+			cli.DefaultStyles.Path.Printf("    %-46s ", out.Path)
+			if measured {
+				cli.DefaultStyles.Stats.Printf("%10s %10s %10s\n", FormatBytes(out.Bytes), FormatBytes(out.Gzip), FormatBytes(out.Brotli))
+			} else {
+				cli.DefaultStyles.Stats.Printf("%10s\n", FormatBytes(out.Bytes))
+			}
+		}
+	}
+
+	// Static files
+	if len(result.Public) > 0 {
+		var bytes int64
+		copied := 0
+		for _, file := range result.Public {
+			bytes += file.Bytes
+			if file.Copied {
+				copied++
+			}
+		}
+		cli.DefaultStyles.Key.Printf("  %s Public:", cli.IconsDefault.Space)
+		cli.DefaultStyles.Stats.Printf(" %d files, %s (%d copied, %d unchanged)\n", len(result.Public), FormatBytes(bytes), copied, len(result.Public)-copied)
+	}
+
 	// Module count
 	modulesStr := fmt.Sprintf("%d", result.ModuleCount)
 	if result.ModuleCount == 1 {
@@ -84,47 +122,143 @@ func PrintReport(result BuildResult) {
 	cli.DefaultStyles.Stats.Printf(" %s\n", timeStr)
 
 	// Print detailed contributors if report flag is enabled
-	if result.Metafile != "" {
-		contributors := getContributors(result.Metafile)
-		if len(contributors) > 0 {
-			fmt.Println()
-			cli.DefaultStyles.Section.Println("Top contributors:")
-			for i := 0; i < len(contributors) && i < 5; i++ {
-				item := contributors[i]
-				sizeKB := float64(item.Bytes) / 1024
-				sizeStr := fmt.Sprintf("%.1f KB", sizeKB)
-				cli.DefaultStyles.Dim.Printf("  %-50s ", item.Path)
-				cli.DefaultStyles.Value.Printf("%10s\n", sizeStr)
-			}
+	if result.Metafile != "" && result.Report {
+		printContributors(getContributors(result.Metafile))
+	}
+
+	fmt.Println()
+}
+
+// printContributors lists the modules adding the most to the output,
+// followed by the modules that tree shaking removed entirely
+func printContributors(contributors []contributorItem) {
+	if len(contributors) == 0 {
+		return
+	}
+
+	fmt.Println()
+	cli.DefaultStyles.Section.Println("Top contributors:")
+	cli.DefaultStyles.Dim.Printf("  %-50s %10s %10s\n", "", "output", "source")
+	shown := 0
+	for _, item := range contributors {
+		if shown == 5 || item.Output == 0 {
+			break
 		}
+		cli.DefaultStyles.Dim.Printf("  %-50s ", item.Path)
+		cli.DefaultStyles.Value.Printf("%10s", FormatBytes(int64(item.Output)))
+		cli.DefaultStyles.Dim.Printf(" %10s\n", FormatBytes(int64(item.Source)))
+		shown++
 	}
 
+	var shaken []contributorItem
+	for _, item := range contributors {
+		if item.Output == 0 && item.Source > 0 {
+			shaken = append(shaken, item)
+		}
+	}
+	if len(shaken) == 0 {
+		return
+	}
+
+	fmt.Println()
+	cli.DefaultStyles.Section.Printf("Tree-shaken to zero (%d):\n", len(shaken))
+	for i, item := range shaken {
+		if i == 5 {
+			cli.DefaultStyles.Dim.Printf("  ... and %d more\n", len(shaken)-i)
+			break
+		}
+		cli.DefaultStyles.Warn.Printf("  %s ", cli.IconsDefault.Warn)
+		cli.DefaultStyles.Dim.Printf("%-48s ", item.Path)
+		cli.DefaultStyles.Dim.Printf("%10s %10s\n", "0 B", FormatBytes(int64(item.Source)))
+	}
+}
+
+// compressed reports whether the compressed sizes of outputs were measured
+func compressed(outputs []OutputFile) bool {
+	for _, out := range outputs {
+		if out.Gzip > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintCombinedReport prints the report of every target followed by totals
+func PrintCombinedReport(results []BuildResult, elapsed time.Duration) {
+	var totalOut, totalGzip, totalBrotli int64
+	for _, result := range results {
+		PrintReport(result)
+		totalOut += result.OutputSize
+		for _, out := range result.Outputs {
+			totalGzip += out.Gzip
+			totalBrotli += out.Brotli
+		}
+	}
+
+	if len(results) == 0 {
+		return
+	}
+
+	buildsStr := fmt.Sprintf("%d", len(results))
+	if len(results) == 1 {
+		buildsStr += " build"
+	} else {
+		buildsStr += " builds"
+	}
+	cli.DefaultStyles.Section.Println("Summary:")
+	cli.DefaultStyles.Key.Printf("  %s Builds:", cli.IconsDefault.Space)
+	cli.DefaultStyles.Stats.Printf(" %s\n", buildsStr)
+	cli.DefaultStyles.Key.Printf("  %s Total size:", cli.IconsDefault.Space)
+	cli.DefaultStyles.Stats.Printf(" %s\n", FormatBytes(totalOut))
+	if totalGzip > 0 {
+		cli.DefaultStyles.Key.Printf("  %s Compressed:", cli.IconsDefault.Space)
+		cli.DefaultStyles.Stats.Printf(" %s gzip, %s brotli\n", FormatBytes(totalGzip), FormatBytes(totalBrotli))
+	}
+	cli.DefaultStyles.Key.Printf("  %s Time:", cli.IconsDefault.Space)
+	cli.DefaultStyles.Stats.Printf(" %dms\n", elapsed.Milliseconds())
 	fmt.Println()
 }
 
-// contributorItem represents a single contributor item
+// contributorItem represents a single contributor item. Source is the
+// size of the original file, Output the bytes it adds to the bundle after
+// tree shaking and minification.
 type contributorItem struct {
-	Path  string
-	Bytes int
+	Path   string
+	Source int
+	Output int
 }
 
-// getContributors extracts and sorts contributors from metadata
+// getContributors extracts contributors from metadata, sorted by the
+// bytes they add to the output
 func getContributors(meta string) []contributorItem {
 	var m MetaFile
 	_ = json.Unmarshal([]byte(meta), &m)
 
+	output := make(map[string]int, len(m.Inputs))
+	for _, out := range m.Outputs {
+		for path, in := range out.Inputs {
+			output[path] += in.BytesInOutput
+		}
+	}
+
 	items := make([]contributorItem, 0, len(m.Inputs))
 	for path, v := range m.Inputs {
-		items = append(items, contributorItem{Path: path, Bytes: v.Bytes})
+		items = append(items, contributorItem{Path: path, Source: v.Bytes, Output: output[path]})
 	}
 
 	if len(items) == 0 {
 		return items
 	}
 
-	// sort by size descending
+	// sort by output size descending, then by source size
 	sort.Slice(items, func(i, j int) bool {
-		return items[i].Bytes > items[j].Bytes
+		if items[i].Output != items[j].Output {
+			return items[i].Output > items[j].Output
+		}
+		if items[i].Source != items[j].Source {
+			return items[i].Source > items[j].Source
+		}
+		return items[i].Path < items[j].Path
 	})
 
 	return items
@@ -0,0 +1,191 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Stats records the outputs of one build. The history file holds one
+// Stats per line; a single Stats also serves as a baseline for diffs.
+type Stats struct {
+	Time      time.Time     `json:"time"`
+	Name      string        `json:"name,omitempty"`
+	ElapsedMs int64         `json:"elapsedMs"`
+	Modules   int           `json:"modules"`
+	Outputs   []OutputStats `json:"outputs"`
+	Public    []string      `json:"public,omitempty"` // Files copied from the public directory
+}
+
+// OutputStats is a single output with the bytes each module adds to it
+type OutputStats struct {
+	Path    string         `json:"path"`
+	Bytes   int64          `json:"bytes"`
+	Gzip    int64          `json:"gzip,omitempty"`
+	Brotli  int64          `json:"brotli,omitempty"`
+	Modules map[string]int `json:"modules"`
+}
+
+// Output returns the output at path, or nil
+func (s *Stats) Output(path string) *OutputStats {
+	for i := range s.Outputs {
+		if s.Outputs[i].Path == path {
+			return &s.Outputs[i]
+		}
+	}
+	return nil
+}
+
+// StatsFromMeta builds stats from a metafile. Source maps are left out.
+func StatsFromMeta(meta *MetaFile) *Stats {
+	stats := &Stats{Modules: len(meta.Inputs)}
+	for path, out := range meta.Outputs {
+		if filepath.Ext(path) == ".map" {
+			continue
+		}
+		modules := make(map[string]int, len(out.Inputs))
+		for input, in := range out.Inputs {
+			modules[input] = in.BytesInOutput
+		}
+		stats.Outputs = append(stats.Outputs, OutputStats{Path: path, Bytes: int64(out.Bytes), Modules: modules})
+	}
+	sort.Slice(stats.Outputs, func(i, j int) bool {
+		return stats.Outputs[i].Path < stats.Outputs[j].Path
+	})
+	return stats
+}
+
+// NewStats returns the stats of a finished build
+func NewStats(result BuildResult) (*Stats, error) {
+	meta, err := ParseMetafile(result.Metafile)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := StatsFromMeta(meta)
+	stats.Time = time.Now().UTC()
+	stats.Name = result.Name
+	stats.ElapsedMs = result.Elapsed.Milliseconds()
+	for _, file := range result.Outputs {
+		if out := stats.Output(filepath.ToSlash(file.Path)); out != nil {
+			out.Gzip, out.Brotli = file.Gzip, file.Brotli
+		}
+	}
+	for _, file := range result.Public {
+		stats.Public = append(stats.Public, file.Path)
+	}
+	return stats, nil
+}
+
+// AppendHistory appends stats as one line to the history file at path
+func AppendHistory(path string, stats *Stats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// RecordHistory appends the stats of a build to the history file of
+// its target, if it has one
+func RecordHistory(opts Options, result BuildResult) error {
+	if opts.History == "" || opts.DryRun {
+		return nil
+	}
+	stats, err := NewStats(result)
+	if err != nil {
+		return err
+	}
+	return AppendHistory(opts.History, stats)
+}
+
+// WriteStats saves stats as an indented JSON baseline file
+func WriteStats(path string, stats *Stats) error {
+	data, err := json.MarshalIndent(stats, "", "\t")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// MergeStats combines the stats of several targets built together
+func MergeStats(all ...*Stats) *Stats {
+	merged := &Stats{}
+	for _, s := range all {
+		if s.Time.After(merged.Time) {
+			merged.Time = s.Time
+		}
+		merged.ElapsedMs = max(merged.ElapsedMs, s.ElapsedMs)
+		merged.Modules += s.Modules
+		merged.Outputs = append(merged.Outputs, s.Outputs...)
+	}
+	return merged
+}
+
+// LoadStats reads a stats baseline, a history file or an esbuild
+// metafile. For history files the latest entry of every target is used.
+func LoadStats(path string) (*Stats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe map[string]json.RawMessage
+	if json.Unmarshal(data, &probe) == nil {
+		if _, ok := probe["inputs"]; ok {
+			meta, err := ParseMetafile(string(data))
+			if err != nil {
+				return nil, err
+			}
+			return StatsFromMeta(meta), nil
+		}
+	}
+
+	// One Stats per line; an indented baseline is a single value
+	latest := make(map[string]*Stats)
+	var names []string
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var s Stats
+		if err := dec.Decode(&s); err != nil {
+			return nil, err
+		}
+		if _, ok := latest[s.Name]; !ok {
+			names = append(names, s.Name)
+		}
+		latest[s.Name] = &s
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no build stats in " + path)
+	}
+
+	all := make([]*Stats, len(names))
+	for i, name := range names {
+		all[i] = latest[name]
+	}
+	if len(all) == 1 {
+		return all[0], nil
+	}
+	return MergeStats(all...), nil
+}
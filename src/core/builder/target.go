@@ -0,0 +1,39 @@
+package builder
+
+import "github.com/evanw/esbuild/pkg/api"
+
+// MapFormat maps string to api.Format
+func MapFormat(format string) api.Format {
+	switch format {
+	case "esm":
+		return api.FormatESModule
+	case "cjs":
+		return api.FormatCommonJS
+	default:
+		return api.FormatIIFE
+	}
+}
+
+// MapPlatform maps string to api.Platform
+func MapPlatform(platform string) api.Platform {
+	switch platform {
+	case "node":
+		return api.PlatformNode
+	case "neutral":
+		return api.PlatformNeutral
+	default:
+		return api.PlatformBrowser
+	}
+}
+
+// ApplyPreset configures JSX handling for a framework preset
+func ApplyPreset(preset string, opts *api.BuildOptions) {
+	switch preset {
+	case "react":
+		opts.JSX = api.JSXAutomatic
+		opts.JSXImportSource = "react"
+	case "preact":
+		opts.JSX = api.JSXAutomatic
+		opts.JSXImportSource = "preact"
+	}
+}
@@ -0,0 +1,202 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/kalokaradia/jspackr/src/config"
+)
+
+// virtualNamespace is the esbuild namespace of virtual modules
+const virtualNamespace = "jspackr-virtual"
+
+// virtualPlugin serves the configured virtual modules. Their sources are
+// generated on every build, so watch rebuilds pick up a new commit or
+// command output.
+func virtualPlugin(modules map[string]config.VirtualModule) api.Plugin {
+	specifiers := make([]string, 0, len(modules))
+	for specifier := range modules {
+		specifiers = append(specifiers, regexp.QuoteMeta(specifier))
+	}
+	sort.Strings(specifiers)
+	filter := "^(" + strings.Join(specifiers, "|") + ")$"
+
+	return api.Plugin{
+		Name: "jspackr:virtual",
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: filter}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				return api.OnResolveResult{Path: args.Path, Namespace: virtualNamespace}, nil
+			})
+			build.OnLoad(api.OnLoadOptions{Filter: ".*", Namespace: virtualNamespace}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				m := modules[args.Path]
+				contents, err := virtualSource(m)
+				if err != nil {
+					return api.OnLoadResult{}, fmt.Errorf("virtual module %s: %w", args.Path, err)
+				}
+				dir, err := filepath.Abs(m.Dir)
+				if err != nil {
+					return api.OnLoadResult{}, err
+				}
+				loader := loaders[m.Loader]
+				if m.Loader == "" {
+					loader = api.LoaderJS
+				}
+				return api.OnLoadResult{Contents: &contents, Loader: loader, ResolveDir: dir}, nil
+			})
+		},
+	}
+}
+
+// virtualSource returns the source of a virtual module
+func virtualSource(m config.VirtualModule) (string, error) {
+	switch {
+	case m.Command != "":
+		return runCommand(m.Command, m.Dir)
+	case m.Generate != "":
+		return generate(m.Generate, m.Dir)
+	default:
+		return m.Contents, nil
+	}
+}
+
+// runCommand runs command in the platform shell and returns its output
+func runCommand(command, dir string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", command, err)
+	}
+	return string(out), nil
+}
+
+// generate runs a built-in generator. Each exports its values by name
+// and one of them as the default export.
+func generate(generator, dir string) (string, error) {
+	var values [][2]string
+	add := func(name string, value any) {
+		data, _ := json.Marshal(value)
+		values = append(values, [2]string{name, string(data)})
+	}
+
+	switch generator {
+	case "git":
+		if err := addGitInfo(dir, add); err != nil {
+			return "", err
+		}
+	case "timestamp":
+		add("timestamp", time.Now().UTC().Format(time.RFC3339))
+	case "version":
+		version, err := packageVersionAt(dir)
+		if err != nil {
+			return "", err
+		}
+		add("version", version)
+	case "build-info":
+		// Missing git or package.json leaves the value empty rather than
+		// failing builds outside a repository
+		if err := addGitInfo(dir, add); err != nil {
+			add("commit", "")
+			add("shortCommit", "")
+			add("branch", "")
+			add("dirty", false)
+		}
+		version, _ := packageVersionAt(dir)
+		add("version", version)
+		add("timestamp", time.Now().UTC().Format(time.RFC3339))
+	default:
+		return "", fmt.Errorf("unknown generator %q", generator)
+	}
+
+	var b strings.Builder
+	names := make([]string, len(values))
+	for i, v := range values {
+		fmt.Fprintf(&b, "export const %s = %s;\n", v[0], v[1])
+		names[i] = v[0]
+	}
+	if generator == "build-info" {
+		fmt.Fprintf(&b, "export default { %s };\n", strings.Join(names, ", "))
+	} else {
+		fmt.Fprintf(&b, "export default %s;\n", names[0])
+	}
+	return b.String(), nil
+}
+
+// addGitInfo adds the commit, branch and working tree state of the
+// repository at dir
+func addGitInfo(dir string, add func(string, any)) error {
+	git := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	commit, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	branch, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return err
+	}
+	status, err := git("status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	add("commit", commit)
+	add("shortCommit", commit[:min(7, len(commit))])
+	add("branch", branch)
+	add("dirty", status != "")
+	return nil
+}
+
+// packageVersionAt returns the version of the nearest package.json at or
+// above dir
+func packageVersionAt(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(abs, "package.json"))
+		if err == nil {
+			var pkg struct {
+				Version string `json:"version"`
+			}
+			if err := json.Unmarshal(data, &pkg); err != nil {
+				return "", fmt.Errorf("%s: %w", filepath.Join(abs, "package.json"), err)
+			}
+			return pkg.Version, nil
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", errors.New("no package.json found")
+		}
+		abs = parent
+	}
+}
@@ -0,0 +1,4 @@
+package plugin
+
+// MaxRestarts exposes maxRestarts to the tests of package plugin_test
+const MaxRestarts = maxRestarts
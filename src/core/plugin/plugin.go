@@ -0,0 +1,165 @@
+// Package plugin is the API for extending jspackr builds from Go.
+//
+// A plugin registers itself from an init function:
+//
+//	func init() {
+//		plugin.Register(plugin.Plugin{
+//			Name: "banner",
+//			Setup: func(build plugin.Build, options map[string]any) error {
+//				build.OnLoad(plugin.Filter{Filter: `\.txt$`}, func(args plugin.LoadArgs) (plugin.LoadResult, error) {
+//					...
+//				})
+//				return nil
+//			},
+//		})
+//	}
+//
+// and is linked into a custom jspackr binary with a blank import in
+// src/main/plugins.go. Builds enable it by name in the "plugins" config key.
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Plugin is a named set of build hooks
+type Plugin struct {
+	Name string
+	// Setup registers the hooks of the plugin. It runs once per build
+	// with the options given in the config. A returned error fails the build.
+	Setup func(build Build, options map[string]any) error
+}
+
+// Build lets a plugin hook into the phases of a build
+type Build interface {
+	// Info describes the build being set up
+	Info() Info
+	// OnStart runs before every build
+	OnStart(fn func() error)
+	// OnResolve runs for import paths matching filter
+	OnResolve(filter Filter, fn func(ResolveArgs) (ResolveResult, error))
+	// OnLoad runs for resolved paths matching filter
+	OnLoad(filter Filter, fn func(LoadArgs) (LoadResult, error))
+	// OnEnd runs after every build, including failed ones
+	OnEnd(fn func(EndResult) error)
+}
+
+// Info describes a build
+type Info struct {
+	Name     string `json:"name"`
+	Input    string `json:"input"`
+	Output   string `json:"output"`
+	Format   string `json:"format"`
+	Platform string `json:"platform"`
+	Minify   bool   `json:"minify"`
+}
+
+// Filter selects the paths a hook runs for. Filter is a Go regular
+// expression; Namespace defaults to every namespace.
+type Filter struct {
+	Filter    string `json:"filter"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ResolveArgs describes an import being resolved. Kind is one of
+// "entry-point", "import-statement", "require-call", "dynamic-import",
+// "require-resolve", "import-rule", "composes-from" or "url-token".
+type ResolveArgs struct {
+	Path       string `json:"path"`
+	Importer   string `json:"importer"`
+	Namespace  string `json:"namespace"`
+	ResolveDir string `json:"resolveDir"`
+	Kind       string `json:"kind"`
+	PluginData any    `json:"pluginData,omitempty"`
+}
+
+// ResolveResult tells the bundler where an import points. An empty Path
+// passes the import on to the next plugin or the default resolver.
+type ResolveResult struct {
+	Path       string    `json:"path,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	External   bool      `json:"external,omitempty"`
+	PluginData any       `json:"pluginData,omitempty"`
+	Errors     []Message `json:"errors,omitempty"`
+	Warnings   []Message `json:"warnings,omitempty"`
+}
+
+// LoadArgs describes a module being loaded
+type LoadArgs struct {
+	Path       string `json:"path"`
+	Namespace  string `json:"namespace"`
+	Suffix     string `json:"suffix"`
+	PluginData any    `json:"pluginData,omitempty"`
+}
+
+// LoadResult provides the contents of a module. A nil Contents passes
+// the module on to the next plugin or the default loader. Loader is one
+// of "js", "jsx", "ts", "tsx", "css", "json", "text", "base64",
+// "dataurl", "file", "binary" or "empty".
+type LoadResult struct {
+	Contents   *string   `json:"contents,omitempty"`
+	Loader     string    `json:"loader,omitempty"`
+	ResolveDir string    `json:"resolveDir,omitempty"`
+	PluginData any       `json:"pluginData,omitempty"`
+	WatchFiles []string  `json:"watchFiles,omitempty"`
+	Errors     []Message `json:"errors,omitempty"`
+	Warnings   []Message `json:"warnings,omitempty"`
+}
+
+// EndResult describes a finished build
+type EndResult struct {
+	Errors   []Message `json:"errors,omitempty"`
+	Warnings []Message `json:"warnings,omitempty"`
+	Metafile string    `json:"metafile,omitempty"`
+}
+
+// Message is an error or warning, optionally pointing at a location.
+// Line is 1-based and Column 0-based, as in esbuild.
+type Message struct {
+	Text     string `json:"text"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	LineText string `json:"lineText,omitempty"`
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Plugin)
+)
+
+// Register makes a plugin available to builds. It panics if the name is
+// empty or already registered, since both are programming errors.
+func Register(p Plugin) {
+	mu.Lock()
+	defer mu.Unlock()
+	if p.Name == "" || p.Setup == nil {
+		panic("plugin: Register needs a name and a Setup function")
+	}
+	if _, dup := registry[p.Name]; dup {
+		panic(fmt.Sprintf("plugin: %q registered twice", p.Name))
+	}
+	registry[p.Name] = p
+}
+
+// Lookup returns the registered plugin with the given name
+func Lookup(name string) (Plugin, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := registry[name]
+	return p, ok
+}
+
+// Names returns the names of all registered plugins in order
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
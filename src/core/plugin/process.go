@@ -0,0 +1,338 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// External plugins are executables that jspackr starts for every build
+// and talks to with JSON-RPC 2.0 over stdin and stdout, one message per
+// line. jspackr sends the requests:
+//
+//	setup   {"options": {...}, "build": Info}  -> SetupResult
+//	resolve {"hook": i, ...ResolveArgs}        -> ResolveResult
+//	load    {"hook": i, ...LoadArgs}           -> LoadResult
+//	start   {}                                 -> {}
+//	end     EndResult                          -> {}
+//
+// where hook is the index of the matching filter in the setup result.
+// The "shutdown" notification ends the plugin when the build is done;
+// its stdin is closed right after. Requests may be sent concurrently, so
+// responses are matched by id. Anything the plugin writes to stderr is
+// passed through. A plugin that exits during the build is restarted.
+// Restarts and output that is not a response are reported through the
+// warn function given to External.
+
+const (
+	// maxRestarts limits how often a crashing plugin is restarted per build
+	maxRestarts = 3
+	// shutdownTimeout is how long a plugin may take to exit before it is killed
+	shutdownTimeout = 2 * time.Second
+)
+
+// SetupResult declares the hooks of an external plugin
+type SetupResult struct {
+	OnResolve []Filter `json:"onResolve"`
+	OnLoad    []Filter `json:"onLoad"`
+	OnStart   bool     `json:"onStart"`
+	OnEnd     bool     `json:"onEnd"`
+}
+
+// errExited fails the requests pending when a plugin process exits
+var errExited = errors.New("plugin process exited")
+
+// External returns a plugin that runs command as an external plugin,
+// calling warn, if set, with problems that do not fail the build
+func External(name string, command []string, warn func(string)) Plugin {
+	return Plugin{
+		Name: name,
+		Setup: func(build Build, options map[string]any) error {
+			if len(command) == 0 {
+				return errors.New("no command given")
+			}
+			p := &process{name: name, command: command, warn: warn, setup: setupParams{Options: options, Build: build.Info()}}
+			hooks, err := p.start()
+			if err != nil {
+				return err
+			}
+			p.register(build, hooks)
+			return nil
+		},
+	}
+}
+
+type setupParams struct {
+	Options map[string]any `json:"options"`
+	Build   Info           `json:"build"`
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// process is a running external plugin and restarts it after a crash
+type process struct {
+	name    string
+	command []string
+	warn    func(string)
+	setup   setupParams
+
+	mu       sync.Mutex
+	conn     *conn
+	restarts int
+	closed   bool
+}
+
+// conn is one run of the plugin executable
+type conn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	done   chan struct{} // closed once the process has exited
+	warn   func(string)
+	writeM sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan rpcMessage
+}
+
+// start launches the executable and runs the setup handshake
+func (p *process) start() (SetupResult, error) {
+	cmd := exec.Command(p.command[0], p.command[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return SetupResult{}, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return SetupResult{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return SetupResult{}, err
+	}
+
+	c := &conn{cmd: cmd, stdin: stdin, done: make(chan struct{}), warn: p.warn, pending: make(map[int64]chan rpcMessage)}
+	go c.read(stdout)
+
+	var hooks SetupResult
+	if err := c.call("setup", p.setup, &hooks); err != nil {
+		c.kill()
+		return SetupResult{}, fmt.Errorf("setup: %w", err)
+	}
+	p.conn = c
+	return hooks, nil
+}
+
+// register forwards the declared hooks to the plugin process
+func (p *process) register(build Build, hooks SetupResult) {
+	if hooks.OnStart {
+		build.OnStart(func() error {
+			return p.call("start", struct{}{}, nil)
+		})
+	}
+	for i, filter := range hooks.OnResolve {
+		hook := i
+		build.OnResolve(filter, func(args ResolveArgs) (ResolveResult, error) {
+			var res ResolveResult
+			err := p.call("resolve", struct {
+				Hook int `json:"hook"`
+				ResolveArgs
+			}{hook, args}, &res)
+			return res, err
+		})
+	}
+	for i, filter := range hooks.OnLoad {
+		hook := i
+		build.OnLoad(filter, func(args LoadArgs) (LoadResult, error) {
+			var res LoadResult
+			err := p.call("load", struct {
+				Hook int `json:"hook"`
+				LoadArgs
+			}{hook, args}, &res)
+			return res, err
+		})
+	}
+	build.OnEnd(func(result EndResult) error {
+		var err error
+		if hooks.OnEnd {
+			err = p.call("end", result, nil)
+		}
+		p.close()
+		return err
+	})
+}
+
+// call sends a request, restarting the plugin if it has exited
+func (p *process) call(method string, params, result any) error {
+	for {
+		c, err := p.current()
+		if err != nil {
+			return err
+		}
+		err = c.call(method, params, result)
+		if !errors.Is(err, errExited) {
+			return err
+		}
+		p.exited(c)
+	}
+}
+
+// current returns the running connection, restarting the plugin if needed
+func (p *process) current() (*conn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, errors.New("plugin was shut down")
+	}
+	if p.conn != nil {
+		return p.conn, nil
+	}
+	if p.restarts >= maxRestarts {
+		return nil, fmt.Errorf("plugin process exited %d times, giving up", p.restarts+1)
+	}
+	p.restarts++
+	if p.warn != nil {
+		p.warn(fmt.Sprintf("Plugin %s exited, restarting (%d/%d)", p.name, p.restarts, maxRestarts))
+	}
+	if _, err := p.start(); err != nil {
+		return nil, err
+	}
+	return p.conn, nil
+}
+
+// exited forgets c once its process is gone so that the next call restarts it
+func (p *process) exited(c *conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == c {
+		p.conn = nil
+	}
+}
+
+// close shuts the plugin down at the end of the build
+func (p *process) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.conn != nil {
+		p.conn.shutdown()
+		p.conn = nil
+	}
+}
+
+// call sends one request and waits for its response
+func (c *conn) call(method string, params, result any) error {
+	c.mu.Lock()
+	if c.pending == nil {
+		c.mu.Unlock()
+		return errExited
+	}
+	c.nextID++
+	id := c.nextID
+	reply := make(chan rpcMessage, 1)
+	c.pending[id] = reply
+	c.mu.Unlock()
+
+	if err := c.send(rpcMessage{ID: &id, Method: method, Params: params}); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return errExited
+	}
+
+	msg, ok := <-reply
+	if !ok {
+		return errExited
+	}
+	if msg.Error != nil {
+		return errors.New(msg.Error.Message)
+	}
+	if result == nil || len(msg.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(msg.Result, result); err != nil {
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+	return nil
+}
+
+// send writes one message as a line
+func (c *conn) send(msg rpcMessage) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeM.Lock()
+	defer c.writeM.Unlock()
+	_, err = c.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// read delivers responses until the plugin closes its stdout, then
+// fails every pending request
+func (c *conn) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.ID == nil {
+			if c.warn != nil {
+				c.warn("Ignoring invalid plugin output: " + scanner.Text())
+			}
+			continue
+		}
+		c.mu.Lock()
+		reply, ok := c.pending[*msg.ID]
+		delete(c.pending, *msg.ID)
+		c.mu.Unlock()
+		if ok {
+			reply <- msg
+		}
+	}
+
+	c.mu.Lock()
+	for _, reply := range c.pending {
+		close(reply)
+	}
+	c.pending = nil
+	c.mu.Unlock()
+	c.cmd.Wait()
+	close(c.done)
+}
+
+// shutdown asks the plugin to exit and kills it if it does not
+func (c *conn) shutdown() {
+	c.send(rpcMessage{Method: "shutdown"})
+	c.stdin.Close()
+	select {
+	case <-c.done:
+	case <-time.After(shutdownTimeout):
+		c.kill()
+	}
+}
+
+// kill stops the plugin immediately
+func (c *conn) kill() {
+	c.stdin.Close()
+	c.cmd.Process.Kill()
+	<-c.done
+}
@@ -0,0 +1,161 @@
+package plugin_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/core/builder"
+	"github.com/kalokaradia/jspackr/src/core/plugin"
+)
+
+// envPlugin is the reference plugin from examples/stdio-plugin, built once
+// by TestMain
+var envPlugin string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "jspackr-plugin")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	envPlugin = filepath.Join(dir, "stdio-plugin")
+	if runtime.GOOS == "windows" {
+		envPlugin += ".exe"
+	}
+	build := exec.Command("go", "build", "-o", envPlugin, "github.com/kalokaradia/jspackr/examples/stdio-plugin")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "building the example plugin:", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// buildWith bundles a file importing source through the plugin command
+// and returns the bundle
+func buildWith(t *testing.T, command []string, source string) (string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	input := filepath.Join(dir, "index.js")
+	if err := os.WriteFile(input, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "dist", "out.js")
+	_, err := builder.Build(builder.Options{
+		Input:   input,
+		Output:  output,
+		Format:  "esm",
+		Plugins: []config.PluginConfig{{Name: "env", Command: command, Options: map[string]any{"prefix": "APP_"}}},
+	})
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(output)
+	return string(data), err
+}
+
+// wrapper writes a shell script that logs "start" to a log file every
+// time it runs, then runs body with $PLUGIN set to the example plugin
+func wrapper(t *testing.T, body string) (command []string, log string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("wrapper scripts need a POSIX shell")
+	}
+	dir := t.TempDir()
+	log = filepath.Join(dir, "log")
+	script := filepath.Join(dir, "plugin.sh")
+	content := fmt.Sprintf("#!/bin/sh\nPLUGIN=%q\nLOG=%q\necho start >> \"$LOG\"\n%s\n", envPlugin, log, body)
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return []string{script}, log
+}
+
+// logLines returns the lines a wrapper logged
+func logLines(t *testing.T, log string) []string {
+	t.Helper()
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Fields(string(data))
+}
+
+func TestExternalRoundTrip(t *testing.T) {
+	t.Setenv("APP_GREETING", "hello from the environment")
+	out, err := buildWith(t, []string{envPlugin}, "import greeting from \"env:APP_GREETING\";\nconsole.log(greeting);\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"hello from the environment"`) {
+		t.Errorf("bundle lacks the loaded value:\n%s", out)
+	}
+}
+
+func TestExternalLocatedError(t *testing.T) {
+	_, err := buildWith(t, []string{envPlugin}, "const x = 1;\nimport secret from \"env:SECRET\";\nconsole.log(x, secret);\n")
+	if err == nil {
+		t.Fatal("build succeeded, want an error")
+	}
+	msg := err.Error()
+	for _, want := range []string{"[plugin env]", "SECRET may not be read", "index.js:2:20", `import secret from "env:SECRET";`} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error lacks %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestExternalRestartsAfterCrash(t *testing.T) {
+	t.Setenv("APP_NAME", "restarted")
+	// The first run exits right after setup: head passes a single request
+	// on, so the plugin sees its stdin close
+	command, log := wrapper(t, `if [ "$(wc -l < "$LOG")" -gt 1 ]; then exec "$PLUGIN"; fi
+head -n 1 | "$PLUGIN"`)
+
+	out, err := buildWith(t, command, "import name from \"env:APP_NAME\";\nconsole.log(name);\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"restarted"`) {
+		t.Errorf("bundle lacks the loaded value:\n%s", out)
+	}
+	if starts := len(logLines(t, log)); starts != 2 {
+		t.Errorf("plugin started %d times, want 2", starts)
+	}
+}
+
+func TestExternalGivesUpAfterMaxRestarts(t *testing.T) {
+	command, log := wrapper(t, `head -n 1 | "$PLUGIN"`)
+
+	_, err := buildWith(t, command, "import name from \"env:APP_NAME\";\nconsole.log(name);\n")
+	want := fmt.Sprintf("plugin process exited %d times, giving up", plugin.MaxRestarts+1)
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("error = %v, want %q", err, want)
+	}
+	if starts := len(logLines(t, log)); starts != plugin.MaxRestarts+1 {
+		t.Errorf("plugin started %d times, want %d", starts, plugin.MaxRestarts+1)
+	}
+}
+
+func TestExternalShutsDownAtEnd(t *testing.T) {
+	t.Setenv("APP_NAME", "done")
+	command, log := wrapper(t, `"$PLUGIN"
+echo exit >> "$LOG"`)
+
+	if _, err := buildWith(t, command, "import name from \"env:APP_NAME\";\nconsole.log(name);\n"); err != nil {
+		t.Fatal(err)
+	}
+	// The build waits for the plugin to exit, so the wrapper has logged it
+	if lines := logLines(t, log); strings.Join(lines, " ") != "start exit" {
+		t.Errorf("wrapper logged %q, want the plugin to start and exit once", lines)
+	}
+}
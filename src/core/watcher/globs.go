@@ -0,0 +1,76 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kalokaradia/jspackr/src/core/builder"
+)
+
+// globWatch watches the directories of import.meta.glob patterns so that
+// adding, removing or renaming a matching file triggers a rebuild
+type globWatch struct {
+	watcher *fsnotify.Watcher
+
+	mu    sync.Mutex
+	globs map[builder.Glob]bool
+	dirs  map[string]bool
+}
+
+func newGlobWatch(watcher *fsnotify.Watcher) *globWatch {
+	return &globWatch{
+		watcher: watcher,
+		globs:   make(map[builder.Glob]bool),
+		dirs:    make(map[string]bool),
+	}
+}
+
+// add starts watching the files a pattern can match. Builds call it for
+// every pattern they expand, so patterns added while watching are picked
+// up on the next rebuild.
+func (w *globWatch) add(g builder.Glob) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.globs[g] {
+		return
+	}
+	w.globs[g] = true
+	watchTree(w.watcher, g.Root(), w.dirs)
+}
+
+// changed reports whether event adds, removes or renames a file matching
+// a pattern. New directories are watched too, and count as a change if
+// they already contain matching files.
+func (w *globWatch) changed(event fsnotify.Event, path string) bool {
+	if event.Op&(fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() && event.Op&fsnotify.Create != 0 {
+		watchTree(w.watcher, path, w.dirs)
+		found := false
+		filepath.WalkDir(path, func(file string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && w.matches(file) {
+				found = true
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		return found
+	}
+	return w.matches(path)
+}
+
+// matches reports whether path matches any pattern
+func (w *globWatch) matches(path string) bool {
+	for g := range w.globs {
+		if g.Match(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/core/builder"
+)
+
+// publicWatch keeps the output directories of targets in sync with their
+// public directories, copying only what changed and without rebuilding
+type publicWatch struct {
+	watcher *fsnotify.Watcher
+	targets []builder.Options
+	logger  *cli.Logger
+	dirs    map[string]bool // Absolute public directories
+	watched map[string]bool
+}
+
+func newPublicWatch(watcher *fsnotify.Watcher, targets []builder.Options, logger *cli.Logger) *publicWatch {
+	p := &publicWatch{
+		watcher: watcher,
+		targets: targets,
+		logger:  logger,
+		dirs:    make(map[string]bool),
+		watched: make(map[string]bool),
+	}
+	for _, target := range targets {
+		if target.PublicDir == "" {
+			continue
+		}
+		dir, err := filepath.Abs(target.PublicDir)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() && !p.dirs[dir] {
+			p.dirs[dir] = true
+			watchTree(watcher, dir, p.watched)
+			logger.PrintWatch(dir)
+		}
+	}
+	// Start out in sync, as the first rebuild only happens on a change
+	for _, target := range targets {
+		if _, err := builder.SyncPublic(target); err != nil {
+			logger.Error("Public sync failed: %v", err)
+		}
+	}
+	return p
+}
+
+// handle syncs the targets whose public directory contains path. It
+// reports whether path belongs to a public directory.
+func (p *publicWatch) handle(event fsnotify.Event, path string) bool {
+	inside := false
+	for dir := range p.dirs {
+		if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			inside = true
+		}
+	}
+	if !inside {
+		return false
+	}
+
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			watchTree(p.watcher, path, p.watched)
+		}
+	}
+
+	for _, target := range p.targets {
+		if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+			if err := builder.RemovePublic(target, path); err != nil {
+				p.logger.Error("Public sync failed: %v", err)
+			}
+		}
+		files, err := builder.SyncPublic(target)
+		if err != nil {
+			p.logger.Error("Public sync failed: %v", err)
+			continue
+		}
+		if err := builder.UpdateManifestPublic(target, files); err != nil {
+			p.logger.Error("Manifest update failed: %v", err)
+		}
+		for _, file := range files {
+			if file.Copied {
+				p.logger.Info("Copied %s", file.Path)
+			}
+		}
+	}
+	return true
+}
+
+// watchTree watches dir and its subdirectories, skipping dependencies
+// and hidden directories. seen records the directories already watched.
+func watchTree(watcher *fsnotify.Watcher, dir string, seen map[string]bool) {
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != dir && (d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		if !seen[path] && watcher.Add(path) == nil {
+			seen[path] = true
+		}
+		return nil
+	})
+}
@@ -13,8 +13,8 @@ var (
 	fileHashes = make(map[string][32]byte)
 )
 
-// WatchFiles watch file changes and trigger rebuilds
-func WatchFiles(entry string, opts builder.Options, logger *cli.Logger) error {
+// WatchFiles watch the entry files of all targets and rebuild them on change
+func WatchFiles(targets []builder.Options, logger *cli.Logger) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
@@ -26,18 +26,49 @@ func WatchFiles(entry string, opts builder.Options, logger *cli.Logger) error {
 		logger = cli.New("info")
 	}
 
-	// consistent absolute path
-	entryPath, err := filepath.Abs(entry)
-	if err != nil {
-		return err
+	// consistent absolute paths, each entry watched once
+	entries := make(map[string]bool)
+	for _, target := range targets {
+		entryPath, err := filepath.Abs(target.Input)
+		if err != nil {
+			return err
+		}
+		if entries[entryPath] {
+			continue
+		}
+		entries[entryPath] = true
+
+		// baseline hash
+		if hash, err := HashFile(entryPath); err == nil {
+			fileHashes[entryPath] = hash
+		}
+
+		logger.PrintWatch(entryPath)
 	}
 
-	// baseline hash
-	if hash, err := HashFile(entryPath); err == nil {
-		fileHashes[entryPath] = hash
+	// Every build registers the import.meta.glob patterns it expands, so
+	// that adding a matching file triggers a rebuild
+	globs := newGlobWatch(watcher)
+	for i := range targets {
+		targets[i].OnGlob = globs.add
 	}
+	public := newPublicWatch(watcher, targets, logger)
 
-	logger.PrintWatch(entryPath)
+	build := func() {
+		if err := builder.RunAll(targets); err != nil {
+			logger.Error("Build failed: %v", err)
+		} else {
+			logger.PrintSuccess()
+		}
+	}
+	rebuild := func() {
+		logger.PrintRebuild()
+		build()
+	}
+
+	// The first build starts watching the glob patterns
+	logger.PrintBuildStart()
+	build()
 
 	go func() {
 		for {
@@ -46,33 +77,35 @@ func WatchFiles(entry string, opts builder.Options, logger *cli.Logger) error {
 				if !ok {
 					return
 				}
-				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				eventPath, err := filepath.Abs(event.Name)
+				if err != nil {
 					continue
 				}
-
-				eventPath, err := filepath.Abs(event.Name)
-				if err != nil || eventPath != entryPath {
+				if !entries[eventPath] {
+					if public.handle(event, eventPath) {
+						continue
+					}
+					if globs.changed(event, eventPath) {
+						StartDebounce(300*time.Millisecond, rebuild)
+					}
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
 					continue
 				}
 
 				// debounce: wait 300ms before rebuild
 				StartDebounce(300*time.Millisecond, func() {
-					newHash, err := HashFile(entryPath)
+					newHash, err := HashFile(eventPath)
 					if err != nil {
 						return
 					}
-					if newHash == fileHashes[entryPath] {
+					if newHash == fileHashes[eventPath] {
 						return
 					}
 
-					fileHashes[entryPath] = newHash
-					logger.PrintRebuild()
-
-					if err := builder.Run(opts); err != nil {
-						logger.Error("Build failed: %v", err)
-					} else {
-						logger.PrintSuccess()
-					}
+					fileHashes[eventPath] = newHash
+					rebuild()
 				})
 
 			case err, ok := <-watcher.Errors:
@@ -83,12 +116,13 @@ func WatchFiles(entry string, opts builder.Options, logger *cli.Logger) error {
 		}
 	}()
 
-	// watch entry file
-	if err := watcher.Add(entryPath); err != nil {
-		return err
+	// watch entry files
+	for entryPath := range entries {
+		if err := watcher.Add(entryPath); err != nil {
+			return err
+		}
 	}
 
 	// block forever
 	select {}
 }
-
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/commands"
 	"github.com/kalokaradia/jspackr/src/config"
+	"github.com/kalokaradia/jspackr/src/core/analyzer"
 	"github.com/kalokaradia/jspackr/src/core/builder"
 	"github.com/kalokaradia/jspackr/src/core/watcher"
 	"github.com/kalokaradia/jspackr/src/utils"
@@ -12,7 +15,30 @@ import (
 
 func main() {
 	const version = "0.3.0"
-	flagCfg, configPath, showVersion, help := utils.ParseFlags()
+
+	// Subcommands take over before global flag parsing
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "init":
+			os.Exit(commands.Init(os.Args[2:]))
+		case "config":
+			os.Exit(commands.Config(os.Args[2:]))
+		case "analyze":
+			os.Exit(commands.Analyze(os.Args[2:]))
+		case "diff":
+			os.Exit(commands.Diff(os.Args[2:]))
+		case "graph":
+			os.Exit(commands.Graph(os.Args[2:]))
+		case "why":
+			os.Exit(commands.Why(os.Args[2:]))
+		case "unused":
+			os.Exit(commands.Unused(os.Args[2:]))
+		case "clean":
+			os.Exit(commands.Clean(os.Args[2:]))
+		}
+	}
+
+	flagCfg, configPath, profile, showVersion, help := utils.ParseFlags()
 
 	// Handle version flag
 	if err := utils.ValidateVersionFlag(showVersion); err != nil {
@@ -31,54 +57,101 @@ func main() {
 	}
 
 	// Load configuration
-	finalCfg := config.Default()
-
-	if configPath == "" {
-		defaultConfig, _ := utils.FindConfigFile()
-		if defaultConfig != "" {
-			configPath = defaultConfig
-		}
+	configPath, discovered := utils.ConfigPath(configPath)
+
+	// Problems in the config file are reported together with
+	// validation errors
+	resolved, err := config.Resolve(configPath, profile, discovered, flagCfg)
+	var problems config.Errors
+	if err != nil && !errors.As(err, &problems) {
+		cli.DefaultStyles.Key.Printf("\n✗ Failed to load config: %v\n", err)
+		os.Exit(2)
+	}
+	if len(problems) > 0 {
+		cli.PrintConfigErrors(problems)
+		os.Exit(2)
 	}
+	finalCfg, targets := resolved.Root, resolved.Targets
+
+	logger := cli.New(finalCfg.LogLevel)
+
+	// Print welcome banner
+	cli.PrintTitle()
 
 	if configPath != "" {
-		fileCfg, err := config.Load(configPath)
-		if err != nil {
-			cli.DefaultStyles.Key.Printf("\n✗ Failed to load config: %v\n", err)
-			os.Exit(2)
-		}
-		finalCfg = fileCfg
+		cli.PrintConfigSource(configPath)
 	}
 
-	config.Merge(finalCfg, flagCfg)
+	opts := make([]builder.Options, 0, len(targets))
+	cleaned := make(map[string]bool)
+	for _, target := range targets {
+		// Print full build configuration summary
+		cli.PrintBuildSummary(target)
 
-	if err := config.Validate(finalCfg); err != nil {
-		cli.DefaultStyles.Key.Printf("\n✗ %v\n", err)
-		os.Exit(2)
+		if target.Clean {
+			ok, err := commands.CleanOutput(target, targets, configPath, cleaned, logger)
+			if err != nil {
+				logger.FatalErr(err, "Clean failed")
+			}
+			if !ok {
+				cli.DefaultStyles.Warn.Println("\n⚠ Build cancelled")
+				return
+			}
+		}
+
+		if !prepareTarget(target, logger) {
+			cli.DefaultStyles.Warn.Println("\n⚠ Build cancelled")
+			return
+		}
+
+		o := builder.FromConfig(target)
+		o.Checks = analyzer.Checks(target)
+		opts = append(opts, o)
+	}
+	if len(opts) == 1 {
+		// A single build keeps the plain report without a name label
+		opts[0].Name = ""
 	}
 
-	logger := cli.New(finalCfg.LogLevel)
+	if finalCfg.Watch {
+		logger.Info("Watch mode enabled")
+		watcher.WatchFiles(opts, logger)
+		return
+	}
 
-	// Print welcome banner
-	cli.PrintTitle()
+	// Start build
+	logger.PrintBuildStart()
+	spinner := cli.NewSpinner("Bundling...")
+	spinner.Start()
+
+	if err := builder.RunAll(opts); err != nil {
+		spinner.Stop(false)
+		logger.FatalErr(err, "Build failed")
+	}
+
+	spinner.Stop(true)
 
-	// Print full build configuration summary
-	cli.PrintBuildSummary(finalCfg)
+	logger.PrintSuccess()
+}
 
+// prepareTarget validates the input and output paths of a build target,
+// creating the output directory and confirming overwrites as needed.
+// It returns false if the user cancelled the build.
+func prepareTarget(target *config.Config, logger *cli.Logger) bool {
 	// Validate input path exists
-	if err := config.ValidateInputPath(finalCfg.Input); err != nil {
+	if err := config.ValidateInputPath(target.Input); err != nil {
 		logger.FatalErr(err, "Invalid input path")
 	}
 
 	// Validate output path and handle directory creation
-	outDir := utils.GetOutputParent(finalCfg.Output)
+	outDir := utils.GetOutputParent(target.Output)
 	if outDir != "." {
-		if _, err := config.ValidateOutputPath(finalCfg.Output); err != nil {
+		if _, err := config.ValidateOutputPath(target.Output); err != nil {
 			// Output directory doesn't exist, ask user to create it
 			// Skip confirmation if noConfirm flag is set
-			if !finalCfg.NoConfirm {
+			if !target.NoConfirm {
 				if !cli.ConfirmCreateDir(outDir) {
-					cli.DefaultStyles.Warn.Println("\n⚠ Build cancelled")
-					return
+					return false
 				}
 			}
 			if err := utils.CreateDir(outDir); err != nil {
@@ -88,52 +161,24 @@ func main() {
 		}
 	}
 
-	if err := utils.ValidateOutputFile(finalCfg.Output); err != nil {
+	if err := utils.ValidateOutputFile(target.Output); err != nil {
 		logger.FatalErr(err, "Invalid output path")
 	}
 
 	// Check if we should overwrite existing file
 	// Skip confirmation if force, yes, or noConfirm flags are set
-	if !utils.ConfirmOverwrite(finalCfg.Output, finalCfg.Force, finalCfg.Yes, finalCfg.NoConfirm) {
-		cli.DefaultStyles.Warn.Println("\n⚠ Build cancelled")
-		return
+	if !utils.ConfirmOverwrite(target.Output, target.Force, target.Yes, target.NoConfirm) {
+		return false
 	}
 
 	if outDir != "." {
 		if notEmpty, _ := utils.DirNotEmpty(outDir); notEmpty {
 			logger.WarnWithTip(
 				"Output directory not empty: "+outDir,
-				"Existing files may be overwritten",
+				"Existing files may be overwritten; use --clean to empty it first",
 			)
 		}
 	}
 
-	opts := builder.Options{
-		Input:     finalCfg.Input,
-		Output:    finalCfg.Output,
-		Minify:    finalCfg.Minify,
-		Report:    finalCfg.Report,
-		SourceMap: finalCfg.SourceMap,
-	}
-
-	if finalCfg.Watch {
-		logger.Info("Watch mode enabled")
-		watcher.WatchFiles(finalCfg.Input, opts, logger)
-		return
-	}
-
-	// Start build
-	logger.PrintBuildStart()
-	spinner := cli.NewSpinner("Bundling...")
-	spinner.Start()
-
-	if err := builder.Run(opts); err != nil {
-		spinner.Stop(false)
-		logger.FatalErr(err, "Build failed")
-	}
-
-	spinner.Stop(true)
-
-	logger.PrintSuccess()
+	return true
 }
-
@@ -0,0 +1,10 @@
+package main
+
+// Plugins are compiled into jspackr by importing them in this file for
+// their side effects; each one registers itself with plugin.Register from
+// an init function. Add an import such as
+//
+//	import _ "example.com/acme/jspackr-plugins/banner"
+//
+// rebuild with go build ./src/main and enable the plugin by name in the
+// "plugins" config key.
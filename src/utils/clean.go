@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kalokaradia/jspackr/src/cli"
+	"github.com/kalokaradia/jspackr/src/config"
+)
+
+// CheckCleanDir returns an error if emptying dir could delete more than
+// build outputs: when it is the filesystem root, the home directory, the
+// working directory or one of its parents, or when it contains the config
+// file or an input or public directory of targets
+func CheckCleanDir(dir, configPath string, targets []*config.Config) error {
+	abs, err := realPath(dir)
+	if err != nil {
+		return err
+	}
+
+	if filepath.Dir(abs) == abs {
+		return fmt.Errorf("refusing to clean %s: it is the filesystem root", dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if h, err := realPath(home); err == nil && h == abs {
+			return fmt.Errorf("refusing to clean %s: it is the home directory", dir)
+		}
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if c, err := realPath(cwd); err == nil && within(abs, c) {
+			return fmt.Errorf("refusing to clean %s: it contains the project root", dir)
+		}
+	}
+
+	if configPath != "" {
+		if c, err := realPath(configPath); err == nil && within(abs, c) {
+			return fmt.Errorf("refusing to clean %s: it contains the config file %s", dir, configPath)
+		}
+	}
+	for _, target := range targets {
+		if target.Input == "" {
+			continue
+		}
+		if in, err := realPath(target.Input); err == nil && within(abs, in) {
+			return fmt.Errorf("refusing to clean %s: it contains the input %s", dir, target.Input)
+		}
+		if target.PublicDir == "" {
+			continue
+		}
+		if pub, err := realPath(target.PublicDir); err == nil && within(abs, pub) {
+			return fmt.Errorf("refusing to clean %s: it contains the public directory %s", dir, target.PublicDir)
+		}
+	}
+	return nil
+}
+
+// ConfirmClean asks before emptying dir. Only noConfirm skips the
+// confirmation; yes answers overwrite prompts, not deletions.
+func ConfirmClean(dir string, noConfirm bool) bool {
+	if noConfirm {
+		return true
+	}
+	message := fmt.Sprintf("Delete everything in %s?", dir)
+	return cli.Confirm(message, false)
+}
+
+// CleanDir removes everything inside dir but keeps dir itself. It returns
+// the number of entries removed; a missing dir has none.
+func CleanDir(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	for i, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+// realPath returns the absolute path of path with symbolic links resolved
+// as far as it exists
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+	return abs, nil
+}
+
+// within reports whether path is dir or inside it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
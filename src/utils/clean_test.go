@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kalokaradia/jspackr/src/config"
+)
+
+// cleanProject creates a project with a config file, an input and a public
+// directory, and makes it the working directory
+func cleanProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"conf/jspackr.json", "src/index.js", "public/robots.txt", "dist/app.js"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(dir, filepath.Join(dir, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+	return dir
+}
+
+func TestCheckCleanDir(t *testing.T) {
+	dir := cleanProject(t)
+	t.Setenv("HOME", t.TempDir())
+	home, _ := os.UserHomeDir()
+	targets := []*config.Config{{Input: "src/index.js", PublicDir: "public"}}
+
+	tests := []struct {
+		name string
+		dir  string
+		want string // Part of the error, empty if cleaning is allowed
+	}{
+		{name: "output", dir: "dist"},
+		{name: "missing output", dir: "build"},
+		{name: "root", dir: "/", want: "filesystem root"},
+		{name: "home", dir: home, want: "home directory"},
+		{name: "working directory", dir: ".", want: "project root"},
+		{name: "parent", dir: filepath.Dir(dir), want: "project root"},
+		{name: "config", dir: "conf", want: "config file"},
+		{name: "input", dir: "src", want: "the input"},
+		{name: "public", dir: "public", want: "public directory"},
+		{name: "symlink to project", dir: "linked", want: "project root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckCleanDir(tt.dir, "conf/jspackr.json", targets)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("CheckCleanDir(%q) = %v, want nil", tt.dir, err)
+			case tt.want != "" && err == nil:
+				t.Errorf("CheckCleanDir(%q) = nil, want an error about the %s", tt.dir, tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Errorf("CheckCleanDir(%q) = %v, want an error about the %s", tt.dir, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanDir(t *testing.T) {
+	dir := cleanProject(t)
+	out := filepath.Join(dir, "dist")
+	if err := os.MkdirAll(filepath.Join(out, "assets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := CleanDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("CleanDir removed %d entries, want 2", n)
+	}
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatalf("CleanDir removed the directory itself: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("CleanDir left %d entries", len(entries))
+	}
+
+	if n, err := CleanDir(filepath.Join(dir, "build")); n != 0 || err != nil {
+		t.Errorf("CleanDir of a missing directory = %d, %v, want 0, nil", n, err)
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -10,50 +11,180 @@ import (
 	"github.com/kalokaradia/jspackr/src/config"
 )
 
-// ParseFlags parses command line flags and returns configuration
-func ParseFlags() (*config.Config, string, bool, bool) {
-	cfg := &config.Config{}
-	var configPath string
+// flagKeys maps flag names to the config keys they set
+var flagKeys = map[string]string{
+	"i":          "input",
+	"input":      "input",
+	"o":          "output",
+	"out":        "output",
+	"m":          "minify",
+	"minify":     "minify",
+	"r":          "report",
+	"report":     "report",
+	"s":          "sourcemap",
+	"source":     "sourcemap",
+	"w":          "watch",
+	"watch":      "watch",
+	"log-level":  "logLevel",
+	"format":     "format",
+	"platform":   "platform",
+	"preset":     "preset",
+	"compress":   "compress",
+	"history":    "history",
+	"duplicates": "duplicates",
+	"cycles":     "cycles",
+	"public-dir": "publicDir",
+	"manifest":   "manifest",
+	"clean":      "clean",
+	"f":          "force",
+	"force":      "force",
+	"y":          "yes",
+	"yes":        "yes",
+	"n":          "noConfirm",
+	"no-confirm": "noConfirm",
+}
+
+// ParseFlags parses command line flags and returns configuration,
+// the config file path and profile, and the version and help flags
+func ParseFlags() (*config.Config, string, string, bool, bool) {
 	var showVersion bool
 	var help bool
 
-	flag.StringVar(&configPath, "c", "", "Path to config file")
-	flag.StringVar(&configPath, "config", "", "Path to config file")
-	flag.StringVar(&cfg.Input, "i", "", "Entry file")
-	flag.StringVar(&cfg.Input, "input", "", "Entry file")
-	flag.StringVar(&cfg.Output, "o", "", "Output file")
-	flag.StringVar(&cfg.Output, "out", "", "Output file")
-	flag.BoolVar(&cfg.Minify, "m", false, "Minify")
-	flag.BoolVar(&cfg.Minify, "minify", false, "Minify")
-	flag.BoolVar(&cfg.Report, "r", false, "Build report")
-	flag.BoolVar(&cfg.Report, "report", false, "Build report")
-	flag.StringVar(&cfg.SourceMap, "s", "", "Source map")
-	flag.StringVar(&cfg.SourceMap, "source", "", "Source map")
-	flag.BoolVar(&cfg.Watch, "w", false, "Watch mode")
-	flag.BoolVar(&cfg.Watch, "watch", false, "Watch mode")
-	flag.StringVar(&cfg.LogLevel, "log-level", "", "Log level")
-	// Force flags for non-interactive mode
-	flag.BoolVar(&cfg.Force, "f", false, "Force overwrite (skip confirmation)")
-	flag.BoolVar(&cfg.Force, "force", false, "Force overwrite (skip confirmation)")
-	flag.BoolVar(&cfg.Yes, "y", false, "Yes to overwrite (auto-confirm)")
-	flag.BoolVar(&cfg.Yes, "yes", false, "Yes to overwrite (auto-confirm)")
-	flag.BoolVar(&cfg.NoConfirm, "n", false, "No confirmations (skip all prompts)")
-	flag.BoolVar(&cfg.NoConfirm, "no-confirm", false, "No confirmations (skip all prompts)")
+	cfg, configPath, profile := BindConfigFlags(flag.CommandLine)
 	flag.BoolVar(&showVersion, "v", false, "Version")
 	flag.BoolVar(&showVersion, "version", false, "Version")
 	flag.BoolVar(&help, "h", false, "Help")
 	flag.BoolVar(&help, "help", false, "Help")
 	flag.Parse()
 
-	return cfg, configPath, showVersion, help
+	MarkFlagOrigins(flag.CommandLine, cfg)
+
+	return cfg, *configPath, *profile, showVersion, help
+}
+
+// BindConfigFlags registers the config related flags on fs. It returns
+// the config the flags fill in and pointers to the config path and profile.
+func BindConfigFlags(fs *flag.FlagSet) (*config.Config, *string, *string) {
+	cfg := &config.Config{}
+	var configPath string
+	var profile string
+
+	// The config file and profile can also come from the environment
+	fs.StringVar(&configPath, "c", os.Getenv("JSPACKR_CONFIG"), "Path to config file")
+	fs.StringVar(&configPath, "config", os.Getenv("JSPACKR_CONFIG"), "Path to config file")
+	fs.StringVar(&profile, "p", os.Getenv("JSPACKR_PROFILE"), "Config profile")
+	fs.StringVar(&profile, "profile", os.Getenv("JSPACKR_PROFILE"), "Config profile")
+	fs.StringVar(&cfg.Input, "i", "", "Entry file")
+	fs.StringVar(&cfg.Input, "input", "", "Entry file")
+	fs.StringVar(&cfg.Output, "o", "", "Output file")
+	fs.StringVar(&cfg.Output, "out", "", "Output file")
+	fs.BoolVar(&cfg.Minify, "m", false, "Minify")
+	fs.BoolVar(&cfg.Minify, "minify", false, "Minify")
+	fs.BoolVar(&cfg.Report, "r", false, "Build report")
+	fs.BoolVar(&cfg.Report, "report", false, "Build report")
+	fs.StringVar(&cfg.SourceMap, "s", "", "Source map")
+	fs.StringVar(&cfg.SourceMap, "source", "", "Source map")
+	fs.BoolVar(&cfg.Watch, "w", false, "Watch mode")
+	fs.BoolVar(&cfg.Watch, "watch", false, "Watch mode")
+	fs.StringVar(&cfg.LogLevel, "log-level", "", "Log level")
+	fs.StringVar(&cfg.Format, "format", "", "Output format")
+	fs.StringVar(&cfg.Platform, "platform", "", "Target platform")
+	fs.StringVar(&cfg.Preset, "preset", "", "Framework preset")
+	fs.BoolVar(&cfg.Compress, "compress", false, "Write .gz and .br files")
+	fs.StringVar(&cfg.History, "history", "", "Build stats history file")
+	fs.StringVar(&cfg.Duplicates, "duplicates", "", "Duplicate package check")
+	fs.StringVar(&cfg.Cycles, "cycles", "", "Import cycle check")
+	fs.StringVar(&cfg.PublicDir, "public-dir", "", "Directory copied into the output directory")
+	fs.StringVar(&cfg.Manifest, "manifest", "", "Write a manifest of the files each build wrote")
+	fs.BoolVar(&cfg.Clean, "clean", false, "Empty the output directory before building")
+	// Force flags for non-interactive mode
+	fs.BoolVar(&cfg.Force, "f", false, "Force overwrite (skip confirmation)")
+	fs.BoolVar(&cfg.Force, "force", false, "Force overwrite (skip confirmation)")
+	fs.BoolVar(&cfg.Yes, "y", false, "Yes to overwrite (auto-confirm)")
+	fs.BoolVar(&cfg.Yes, "yes", false, "Yes to overwrite (auto-confirm)")
+	fs.BoolVar(&cfg.NoConfirm, "n", false, "No confirmations (skip all prompts)")
+	fs.BoolVar(&cfg.NoConfirm, "no-confirm", false, "No confirmations (skip all prompts)")
+
+	return cfg, &configPath, &profile
+}
+
+// MarkFlagOrigins records the flags explicitly passed on fs as the origin
+// of their config keys, so that e.g. --minify=false can override
+// "minify": true from the config file
+func MarkFlagOrigins(fs *flag.FlagSet, cfg *config.Config) {
+	fs.Visit(func(f *flag.Flag) {
+		if key, ok := flagKeys[f.Name]; ok {
+			name := "--" + f.Name
+			if len(f.Name) == 1 {
+				name = "-" + f.Name
+			}
+			cfg.SetOrigin(key, config.Origin{Kind: config.OriginFlag, Name: name})
+		}
+	})
 }
 
-// FindConfigFile looks for default config file in current directory
+// FindConfigFile looks for a config file in the current directory and
+// its parents, stopping at the repository root (the first directory
+// containing .git). Besides the jspackr.config.* files, a package.json
+// with a "jspackr" key is accepted.
 func FindConfigFile() (string, error) {
-	if _, err := os.Stat("jspackr.config.json"); err == nil {
-		return "jspackr.config.json", nil
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for dir := cwd; ; {
+		if path := findConfigInDir(dir); path != "" {
+			if rel, err := filepath.Rel(cwd, path); err == nil {
+				return rel, nil
+			}
+			return path, nil
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// ConfigPath returns path, or the config file found by FindConfigFile
+// when path is empty. discovered reports whether the file was found
+// rather than given.
+func ConfigPath(path string) (string, bool) {
+	if path != "" {
+		return path, false
+	}
+	found, _ := FindConfigFile()
+	return found, found != ""
+}
+
+// findConfigInDir returns the config file in dir, or "" if there is none
+func findConfigInDir(dir string) string {
+	for _, name := range config.FileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+
+	pkgPath := filepath.Join(dir, "package.json")
+	data, err := os.ReadFile(pkgPath)
+	if err != nil {
+		return ""
+	}
+	var pkg map[string]json.RawMessage
+	if json.Unmarshal(data, &pkg) != nil {
+		return ""
 	}
-	return "", nil
+	if _, ok := pkg[config.PackageKey]; ok {
+		return pkgPath
+	}
+	return ""
 }
 
 // ValidateVersionFlag checks if version flag is used correctly
@@ -90,6 +221,32 @@ func ShowUsage() {
 	sectionColor.Println("📖 USAGE")
 	descColor.Print("  ")
 	flagColor.Println("jspackr [options]")
+	descColor.Print("  ")
+	flagColor.Println("jspackr <command> [options]")
+	fmt.Println()
+
+	// Commands
+	sectionColor.Println("🧰 COMMANDS")
+	flagColor.Println("  init                   ")
+	descColor.Println("    Create jspackr.config.json interactively (--yes for defaults)")
+	flagColor.Println("  config validate        ")
+	descColor.Println("    Check the config file for unknown keys and invalid values")
+	flagColor.Println("  config schema          ")
+	descColor.Println("    Print the JSON Schema for config files")
+	flagColor.Println("  config print           ")
+	descColor.Println("    Print the resolved config and where each value came from")
+	flagColor.Println("  analyze                ")
+	descColor.Println("    Write an HTML treemap of the bundle (--html <file>, --meta <metafile>)")
+	flagColor.Println("  diff                   ")
+	descColor.Println("    Compare the bundle with a baseline (--base <file>, --save)")
+	flagColor.Println("  graph                  ")
+	descColor.Println("    Print the import graph (--as dot|mermaid|json, --packages, --depth)")
+	flagColor.Println("  why <module|package>   ")
+	descColor.Println("    Show every import path from an entry point to a module")
+	flagColor.Println("  unused                 ")
+	descColor.Println("    List source files and exports the bundle does not use (--root, --ignore)")
+	flagColor.Println("  clean                  ")
+	descColor.Println("    Empty the output directories of all targets (--no-confirm to skip the prompt)")
 	fmt.Println()
 
 	// Description
@@ -119,13 +276,17 @@ func ShowUsage() {
 	descColor.Println("    Path to configuration file")
 	fmt.Println()
 
+	flagColor.Println("  -p, --profile <name>   ")
+	descColor.Println("    Apply a named profile from the config file")
+	fmt.Println()
+
 	// Build options
 	dimColor.Println("  ┌─────────────────────────────────────────────────────────────┐")
 	dimColor.Println("  │                      BUILD OPTIONS                          │")
 	dimColor.Println("  └─────────────────────────────────────────────────────────────┘")
 	fmt.Println()
 
-	flagColor.Println("  -m, --minify           ")
+	flagColor.Println("  -m, --minify[=false]   ")
 	descColor.Println("    Minify the output bundle")
 	fmt.Println()
 
@@ -145,6 +306,46 @@ func ShowUsage() {
 	descColor.Println("    Set log level (debug, info, warn, error)")
 	fmt.Println()
 
+	flagColor.Println("  --format <format>      ")
+	descColor.Println("    Output format (iife, esm, cjs)")
+	fmt.Println()
+
+	flagColor.Println("  --platform <platform>  ")
+	descColor.Println("    Target platform (browser, node, neutral)")
+	fmt.Println()
+
+	flagColor.Println("  --preset <preset>      ")
+	descColor.Println("    Framework preset (vanilla, react, preact)")
+	fmt.Println()
+
+	flagColor.Println("  --compress             ")
+	descColor.Println("    Also write precompressed .gz and .br files")
+	fmt.Println()
+
+	flagColor.Println("  --history <file>       ")
+	descColor.Println("    Append the stats of each build to a JSON lines file")
+	fmt.Println()
+
+	flagColor.Println("  --duplicates <mode>    ")
+	descColor.Println("    Report packages bundled more than once (off, warn, error)")
+	fmt.Println()
+
+	flagColor.Println("  --cycles <mode>        ")
+	descColor.Println("    Report import cycles among your own modules (off, warn, error)")
+	fmt.Println()
+
+	flagColor.Println("  --public-dir <dir>     ")
+	descColor.Println("    Copy a directory into the output directory (default: public)")
+	fmt.Println()
+
+	flagColor.Println("  --manifest <file>      ")
+	descColor.Println("    Write a JSON list of the files each build wrote")
+	fmt.Println()
+
+	flagColor.Println("  --clean                ")
+	descColor.Println("    Empty the output directory before building")
+	fmt.Println()
+
 	// Non-interactive options
 	dimColor.Println("  ┌─────────────────────────────────────────────────────────────┐")
 	dimColor.Println("  │                 NON-INTERACTIVE OPTIONS                     │")
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	globCache   = make(map[string]*regexp.Regexp)
+	globCacheMu sync.Mutex
+)
+
+// MatchGlob reports whether a slash separated path matches pattern.
+// "*" matches within a path segment, "**" across segments, "?" a
+// single character and "{a,b}" either alternative. A pattern without a
+// slash also matches the base name, and a pattern naming a directory
+// matches everything below it.
+func MatchGlob(pattern, path string) bool {
+	path = filepath.ToSlash(path)
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	path = strings.TrimPrefix(path, "./")
+
+	if globRegexp(pattern).MatchString(path) {
+		return true
+	}
+	if strings.HasPrefix(path, strings.TrimSuffix(pattern, "/")+"/") {
+		return true
+	}
+	return !strings.Contains(pattern, "/") && globRegexp(pattern).MatchString(filepath.Base(path))
+}
+
+// MatchGlobExact reports whether a slash separated path matches pattern
+// as a whole, without the directory and base name matching of MatchGlob
+func MatchGlobExact(pattern, path string) bool {
+	return globRegexp(filepath.ToSlash(pattern)).MatchString(filepath.ToSlash(path))
+}
+
+// globRegexp compiles pattern into an anchored regular expression
+func globRegexp(pattern string) *regexp.Regexp {
+	globCacheMu.Lock()
+	defer globCacheMu.Unlock()
+	if re, ok := globCache[pattern]; ok {
+		return re
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	inBraces := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '{':
+			if !inBraces && strings.IndexByte(pattern[i:], '}') > 0 {
+				inBraces = true
+				b.WriteString("(?:")
+			} else {
+				b.WriteString(regexp.QuoteMeta("{"))
+			}
+		case '}':
+			if inBraces {
+				inBraces = false
+				b.WriteString(")")
+			} else {
+				b.WriteString(regexp.QuoteMeta("}"))
+			}
+		case ',':
+			if inBraces {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// "**/" also matches no directory at all
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re := regexp.MustCompile(b.String())
+	globCache[pattern] = re
+	return re
+}